@@ -0,0 +1,21 @@
+package common
+
+import (
+	"mime"
+	"strings"
+)
+
+const (
+	ContentTypeJSON   = "application/json"
+	ContentTypeBinary = "application/octet-stream"
+)
+
+// IsJSON reports whether the content type describes a JSON document, including
+// structured suffixes such as application/problem+json.
+func IsJSON(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	return mediaType == ContentTypeJSON || strings.HasSuffix(mediaType, "+json")
+}
@@ -0,0 +1,39 @@
+package common
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	ErrTooLarge   = errors.New("value exceeds store size limit")
+	ErrNotFound   = errors.New("key not found")
+	ErrInvalidTTL = errors.New("ttl must be positive")
+
+	ErrVersionMismatch = errors.New("version does not match")
+	ErrExists          = errors.New("key already exists")
+
+	ErrNotInteger = errors.New("value is not an integer")
+	ErrWrongType  = errors.New("operation against a key holding the wrong kind of value")
+	ErrBadScore   = errors.New("score must be a finite number")
+	ErrOverflow   = errors.New("increment would overflow")
+
+	ErrUnknownOp     = errors.New("unknown operation")
+	ErrInvalidCursor = errors.New("invalid cursor")
+
+	ErrLoad = errors.New("could not load value from origin")
+)
+
+// TxError reports the operation that made a transaction fail.
+type TxError struct {
+	Index int
+	Err   error
+}
+
+func (e *TxError) Error() string {
+	return fmt.Sprintf("operation %d: %s", e.Index, e.Err)
+}
+
+func (e *TxError) Unwrap() error {
+	return e.Err
+}
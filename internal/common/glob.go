@@ -0,0 +1,40 @@
+package common
+
+// MatchGlob reports whether key matches pattern. Patterns support * (any run of
+// characters), ? (any single byte) and \ to escape either; an empty pattern
+// matches everything. Unlike path.Match it treats slashes like any other
+// character, which keys commonly contain.
+func MatchGlob(pattern, key string) bool {
+	if pattern == "" {
+		return true
+	}
+	// Classic backtracking over the last star, linear for patterns with one star.
+	p, k := 0, 0
+	star, mark := -1, 0
+	for k < len(key) {
+		switch {
+		case p < len(pattern) && pattern[p] == '*':
+			star, mark = p, k
+			p++
+		case p < len(pattern) && pattern[p] == '?':
+			p++
+			k++
+		case p+1 < len(pattern) && pattern[p] == '\\' && pattern[p+1] == key[k]:
+			p += 2
+			k++
+		case p < len(pattern) && pattern[p] != '\\' && pattern[p] == key[k]:
+			p++
+			k++
+		case star >= 0:
+			p = star + 1
+			mark++
+			k = mark
+		default:
+			return false
+		}
+	}
+	for p < len(pattern) && pattern[p] == '*' {
+		p++
+	}
+	return p == len(pattern)
+}
@@ -0,0 +1,27 @@
+package common
+
+import "testing"
+
+func TestMatchGlob(t *testing.T) {
+	tests := []struct {
+		pattern, key string
+		want         bool
+	}{
+		{"", "anything", true},
+		{"*", "", true},
+		{"user:*", "user:1", true},
+		{"user:*", "product:1", false},
+		{"*:1", "user/a:1", true},
+		{"a*b*c", "axxbyyc", true},
+		{"a*b*c", "axxbyy", false},
+		{"user:?", "user:12", false},
+		{"user:??", "user:12", true},
+		{`star\*`, "star*", true},
+		{`star\*`, "starry", false},
+	}
+	for _, tt := range tests {
+		if got := MatchGlob(tt.pattern, tt.key); got != tt.want {
+			t.Errorf("MatchGlob(%q, %q) = %v, want %v", tt.pattern, tt.key, got, tt.want)
+		}
+	}
+}
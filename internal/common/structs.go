@@ -1,18 +1,165 @@
 package common
 
-import "time"
+import (
+	"maps"
+	"slices"
+	"time"
+)
 
 type Meta struct {
-	CreatedAt  time.Time
-	ModifiedAt time.Time
+	CreatedAt   time.Time
+	ModifiedAt  time.Time
+	AccessedAt  time.Time // last read or write before the one returning this meta
+	Version     uint64    // increases on every write of the value, never reused
+	Codec       string    // name of the codec Data was encoded with
+	ContentType string
+}
+
+// Kinds of values. Plain values, stored in Data, have an empty kind; the
+// others keep their contents in the field of the same name.
+const (
+	KindHash = "hash"
+	KindList = "list"
+	KindZSet = "zset"
+	KindSet  = "set"
+)
+
+// ZMember is a member of a sorted set with its score.
+type ZMember struct {
+	Member string  `json:"member"`
+	Score  float64 `json:"score"`
 }
 
 type Value struct {
 	Meta Meta
 	Data []byte
+	Kind string
+	Hash map[string]string
+	List []string
+	ZSet []ZMember // ordered by score, then member
+	Set  []string  // sorted
+}
+
+// Size returns the number of bytes the value accounts for in store limits.
+func (v Value) Size() int {
+	n := len(v.Data)
+	for field, value := range v.Hash {
+		n += len(field) + len(value)
+	}
+	for _, value := range v.List {
+		n += len(value)
+	}
+	for _, m := range v.ZSet {
+		n += len(m.Member) + 8
+	}
+	for _, member := range v.Set {
+		n += len(member)
+	}
+	return n
+}
+
+// Empty reports whether a collection value has no elements left. Plain values
+// are never empty.
+func (v Value) Empty() bool {
+	switch v.Kind {
+	case KindHash:
+		return len(v.Hash) == 0
+	case KindList:
+		return len(v.List) == 0
+	case KindZSet:
+		return len(v.ZSet) == 0
+	case KindSet:
+		return len(v.Set) == 0
+	}
+	return false
+}
+
+// Clone returns a copy of the value that shares no collection with v.
+func (v Value) Clone() Value {
+	v.Hash = maps.Clone(v.Hash)
+	v.List = slices.Clone(v.List)
+	v.ZSet = slices.Clone(v.ZSet)
+	v.Set = slices.Clone(v.Set)
+	return v
 }
 
 type Item struct {
 	Value      Value
-	Expiration time.Time
+	Expiration time.Time     // zero for items that never expire
+	Lease      time.Duration // ttl the expiration was last set from, used by Touch
+}
+
+// Entry is a value addressed by key, as written by batch operations.
+type Entry struct {
+	Key         string
+	Data        []byte
+	ContentType string
+	TTL         time.Duration
+}
+
+// Result is the outcome of a batch operation for a single key. Meta is nil
+// when the key was not found, Err is set when the operation failed.
+type Result struct {
+	Key  string
+	Data []byte
+	Meta *Meta
+	Err  error
+}
+
+// Transaction operations.
+const (
+	OpSet    = "set"
+	OpDelete = "delete"
+	OpCAS    = "cas"
+)
+
+// TxOp is a single operation of a transaction. Version is the version a CAS
+// expects the key to hold.
+type TxOp struct {
+	Op          string
+	Key         string
+	Data        []byte
+	ContentType string
+	TTL         time.Duration
+	Version     uint64
+}
+
+// Keyspace event operations. Deletes carry the reason the store removed the
+// key on its own; deletes asked for by clients have none. A flush applies to
+// every key. Overflow tells a subscriber that events were dropped because it
+// fell behind.
+const (
+	EventSet      = "set"
+	EventDelete   = "delete"
+	EventFlush    = "flush"
+	EventOverflow = "overflow"
+
+	ReasonExpired = "expired"
+	ReasonEvicted = "evicted"
+)
+
+// Event is a change to the keyspace of a store. Version is the version a set
+// wrote.
+type Event struct {
+	Key     string `json:"key,omitempty"`
+	Op      string `json:"op"`
+	Version uint64 `json:"version,omitempty"`
+	Reason  string `json:"reason,omitempty"`
+}
+
+// NoExpiry is the remaining time to live reported for items that never expire.
+const NoExpiry time.Duration = -1
+
+// Expired reports whether the item's expiration has passed at now. Items
+// without an expiration never expire.
+func (i Item) Expired(now time.Time) bool {
+	return !i.Expiration.IsZero() && now.After(i.Expiration)
+}
+
+// TTL returns the time left until the item expires at now, or NoExpiry.
+func (i Item) TTL(now time.Time) time.Duration {
+	if i.Expiration.IsZero() {
+		return NoExpiry
+	}
+	return max(i.Expiration.Sub(now), 0)
 }
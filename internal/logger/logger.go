@@ -124,19 +124,19 @@ func (l *Logger) Log(level logrus.Level, msgf string, args ...any) {
 }
 
 func Debug(msg string) {
-	log.Log(logrus.DebugLevel, msg)
+	log.Log(logrus.DebugLevel, "%s", msg)
 }
 
 func Info(msg string) {
-	log.Log(logrus.InfoLevel, msg)
+	log.Log(logrus.InfoLevel, "%s", msg)
 }
 
 func Warn(msg string) {
-	log.Log(logrus.WarnLevel, msg)
+	log.Log(logrus.WarnLevel, "%s", msg)
 }
 
 func Error(msg string) {
-	log.Log(logrus.ErrorLevel, msg)
+	log.Log(logrus.ErrorLevel, "%s", msg)
 }
 
 func Debugf(msg string, args ...any) {
@@ -0,0 +1,92 @@
+// Package pubsub implements fire-and-forget publish/subscribe messaging
+// between clients of the cache, independent of the stored data.
+package pubsub
+
+import (
+	"context"
+	"sync"
+
+	"github.com/johannessarpola/poor-cache-go/internal/common"
+)
+
+const defaultBuffer = 64
+
+// Message is a message published to a channel.
+type Message struct {
+	Channel string `json:"channel"`
+	Message string `json:"message"`
+}
+
+// Broker delivers published messages to the subscribers of matching channels.
+// Messages are not stored: only subscribers present at publish time get them.
+type Broker struct {
+	mu     sync.RWMutex
+	subs   map[*subscriber]struct{}
+	buffer int
+}
+
+type subscriber struct {
+	patterns []string
+	messages chan Message
+}
+
+// New returns a broker that buffers up to buffer messages per subscriber, or
+// a default number when buffer is not positive.
+func New(buffer int) *Broker {
+	if buffer <= 0 {
+		buffer = defaultBuffer
+	}
+	return &Broker{subs: make(map[*subscriber]struct{}), buffer: buffer}
+}
+
+// Subscribe returns a channel receiving the messages published to channels
+// matching any of patterns, see common.MatchGlob for the syntax. A channel
+// name without wildcards matches only itself. The channel is closed once ctx
+// is done.
+func (b *Broker) Subscribe(ctx context.Context, patterns ...string) <-chan Message {
+	sub := &subscriber{patterns: patterns, messages: make(chan Message, b.buffer)}
+
+	b.mu.Lock()
+	b.subs[sub] = struct{}{}
+	b.mu.Unlock()
+
+	go func() {
+		<-ctx.Done()
+		b.mu.Lock()
+		delete(b.subs, sub)
+		close(sub.messages)
+		b.mu.Unlock()
+	}()
+	return sub.messages
+}
+
+// Publish sends message to the subscribers of channel and returns how many
+// received it. Publishers never wait: subscribers whose buffer is full miss
+// the message.
+func (b *Broker) Publish(channel, message string) int {
+	msg := Message{Channel: channel, Message: message}
+	n := 0
+
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	for sub := range b.subs {
+		if !sub.matches(channel) {
+			continue
+		}
+		select {
+		case sub.messages <- msg:
+			n++
+		default:
+		}
+	}
+	return n
+}
+
+func (sub *subscriber) matches(channel string) bool {
+	for _, pattern := range sub.patterns {
+		if common.MatchGlob(pattern, channel) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,67 @@
+package pubsub
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func receive(t *testing.T, messages <-chan Message) Message {
+	t.Helper()
+	select {
+	case m := <-messages:
+		return m
+	case <-time.After(time.Second):
+		t.Fatalf("expected a message")
+		return Message{}
+	}
+}
+
+func TestPublishToPatterns(t *testing.T) {
+	b := New(0)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	exact := b.Subscribe(ctx, "invalidate.users")
+	pattern := b.Subscribe(ctx, "invalidate.*")
+	other := b.Subscribe(ctx, "metrics")
+
+	if n := b.Publish("invalidate.users", "user:1"); n != 2 {
+		t.Fatalf("expected 2 receivers, got %d", n)
+	}
+	want := Message{Channel: "invalidate.users", Message: "user:1"}
+	if m := receive(t, exact); m != want {
+		t.Fatalf("unexpected message %+v", m)
+	}
+	if m := receive(t, pattern); m != want {
+		t.Fatalf("unexpected message %+v", m)
+	}
+	select {
+	case m := <-other:
+		t.Fatalf("expected no message, got %+v", m)
+	default:
+	}
+}
+
+func TestSlowSubscriberMissesMessages(t *testing.T) {
+	b := New(1)
+	ctx, cancel := context.WithCancel(context.Background())
+	messages := b.Subscribe(ctx, "c")
+
+	if n := b.Publish("c", "first"); n != 1 {
+		t.Fatalf("expected the first message to be delivered, got %d", n)
+	}
+	if n := b.Publish("c", "second"); n != 0 {
+		t.Fatalf("expected a full subscriber to miss the message, got %d", n)
+	}
+
+	cancel()
+	if m := receive(t, messages); m.Message != "first" {
+		t.Fatalf("unexpected message %+v", m)
+	}
+	for range messages {
+	}
+	if n := b.Publish("c", "third"); n != 0 {
+		t.Fatalf("expected no subscribers after cancel, got %d", n)
+	}
+}
@@ -0,0 +1,33 @@
+package rest
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// FlushHandler removes every key of the namespace and responds with the number
+// of items discarded.
+func (s *Service) FlushHandler(c *gin.Context) {
+	n, err := s.storeFor(c).Flush()
+	if err != nil {
+		storeErr(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"flushed": n})
+}
+
+// FlushAllHandler flushes every namespace. Namespaces are flushed one after
+// the other; on failure the ones before have been flushed already.
+func (s *Service) FlushAllHandler(c *gin.Context) {
+	flush := s.store.Flush
+	if s.namespaces != nil {
+		flush = s.namespaces.FlushAll
+	}
+	n, err := flush()
+	if err != nil {
+		storeErr(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"flushed": n})
+}
@@ -0,0 +1,135 @@
+package rest
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/johannessarpola/poor-cache-go/internal/common"
+)
+
+// maxBatchSize caps the number of keys a single batch request may touch.
+const maxBatchSize = 1000
+
+const (
+	statusFound   = "found"
+	statusMissing = "missing"
+	statusStored  = "stored"
+	statusError   = "error"
+)
+
+var errBatchSize = errors.New("too many keys in batch")
+
+type MGetRequest struct {
+	Keys []string `json:"keys" binding:"required"`
+}
+
+// MSetItem is a single write of a batch. JSON values go in Value, anything
+// else base64 encoded in Data together with its ContentType.
+type MSetItem struct {
+	Key         string          `json:"key" binding:"required"`
+	Value       json.RawMessage `json:"value,omitempty"`
+	Data        []byte          `json:"data,omitempty"`
+	ContentType string          `json:"content_type,omitempty"`
+	TTL         string          `json:"ttl,omitempty"`
+}
+
+// entry converts the item to a store entry, parsing its ttl. An item without
+// value or data is rejected.
+func (item MSetItem) entry() (common.Entry, error) {
+	e := common.Entry{Key: item.Key, Data: item.Data, ContentType: item.ContentType}
+	if item.Data == nil {
+		e.Data, e.ContentType = item.Value, common.ContentTypeJSON
+	}
+	if e.Data == nil {
+		return e, errBadJson
+	}
+	if item.TTL != "" {
+		ttl, err := time.ParseDuration(item.TTL)
+		if err != nil {
+			return e, err
+		}
+		e.TTL = ttl
+	}
+	return e, nil
+}
+
+type MSetRequest struct {
+	Items []MSetItem `json:"items" binding:"required,dive"`
+}
+
+// BatchResult is the outcome of a batch operation for one key.
+type BatchResult struct {
+	Key         string          `json:"key"`
+	Status      string          `json:"status"`
+	Value       json.RawMessage `json:"value,omitempty"`
+	Data        []byte          `json:"data,omitempty"`
+	ContentType string          `json:"content_type,omitempty"`
+	Meta        *common.Meta    `json:"meta,omitempty"`
+	Error       string          `json:"error,omitempty"`
+}
+
+func newBatchResult(r common.Result, okStatus string) BatchResult {
+	res := BatchResult{Key: r.Key, Status: okStatus, Meta: r.Meta}
+	switch {
+	case r.Err != nil:
+		res.Status = statusError
+		res.Error = r.Err.Error()
+	case r.Meta == nil:
+		res.Status = statusMissing
+	case r.Data != nil && common.IsJSON(r.Meta.ContentType):
+		res.Value = r.Data
+	case r.Data != nil:
+		res.Data = r.Data
+		res.ContentType = r.Meta.ContentType
+	}
+	return res
+}
+
+func (s *Service) MGetHandler(c *gin.Context) {
+	var req MGetRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, newErr(errBadJson))
+		return
+	}
+	if len(req.Keys) > maxBatchSize {
+		c.JSON(http.StatusBadRequest, newErr(errBatchSize))
+		return
+	}
+
+	results := make([]BatchResult, 0, len(req.Keys))
+	for _, r := range s.storeFor(c).MGet(req.Keys) {
+		results = append(results, newBatchResult(r, statusFound))
+	}
+	c.JSON(http.StatusOK, gin.H{"results": results})
+}
+
+func (s *Service) MSetHandler(c *gin.Context) {
+	var req MSetRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, newErr(errBadJson))
+		return
+	}
+	if len(req.Items) > maxBatchSize {
+		c.JSON(http.StatusBadRequest, newErr(errBatchSize))
+		return
+	}
+
+	entries := make([]common.Entry, 0, len(req.Items))
+	for _, item := range req.Items {
+		e, err := item.entry()
+		if err != nil {
+			c.JSON(http.StatusBadRequest, newErr(errBadJson))
+			return
+		}
+		entries = append(entries, e)
+	}
+
+	results := make([]BatchResult, 0, len(entries))
+	for _, r := range s.storeFor(c).MSet(entries) {
+		results = append(results, newBatchResult(r, statusStored))
+	}
+	c.JSON(http.StatusOK, gin.H{"results": results})
+}
@@ -0,0 +1,150 @@
+package rest
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/johannessarpola/poor-cache-go/internal/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMGetHandler(t *testing.T) {
+	jsonMeta := &common.Meta{Version: 1, ContentType: common.ContentTypeJSON}
+	binMeta := &common.Meta{Version: 2, ContentType: "image/png"}
+
+	tests := []struct {
+		name           string
+		body           string
+		expectedStatus int
+		expectedBody   string
+		mgetFunc       func(keys []string) []common.Result
+	}{
+		{
+			name:           "Mixed results",
+			body:           `{"keys": ["json", "bin", "missing", "broken"]}`,
+			expectedStatus: http.StatusOK,
+			expectedBody: `{"results": [
+				{"key": "json", "status": "found", "value": {"a": 1}, "meta": ` + asJsonStr(jsonMeta) + `},
+				{"key": "bin", "status": "found", "data": "AP8=", "content_type": "image/png", "meta": ` + asJsonStr(binMeta) + `},
+				{"key": "missing", "status": "missing"},
+				{"key": "broken", "status": "error", "error": "assert.AnError general error for testing"}
+			]}`,
+			mgetFunc: func(keys []string) []common.Result {
+				return []common.Result{
+					{Key: keys[0], Data: []byte(`{"a":1}`), Meta: jsonMeta},
+					{Key: keys[1], Data: []byte{0x00, 0xff}, Meta: binMeta},
+					{Key: keys[2]},
+					{Key: keys[3], Err: assert.AnError},
+				}
+			},
+		},
+		{
+			name:           "Missing keys",
+			body:           `{}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   errJson(errBadJson),
+		},
+		{
+			name:           "Too many keys",
+			body:           `{"keys": [` + strings.TrimSuffix(strings.Repeat(`"k",`, maxBatchSize+1), ",") + `]}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   errJson(errBatchSize),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockStore := &MockStore{
+				MGetFunc: tt.mgetFunc,
+			}
+
+			service := New(mockStore)
+			router := gin.Default()
+			router.POST("/mget", service.MGetHandler)
+
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("POST", "/mget", bytes.NewBufferString(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			assert.JSONEq(t, tt.expectedBody, w.Body.String())
+		})
+	}
+}
+
+func TestMSetHandler(t *testing.T) {
+	tests := []struct {
+		name           string
+		body           string
+		expectedStatus int
+		expectedBody   string
+		msetFunc       func(entries []common.Entry) []common.Result
+	}{
+		{
+			name:           "Json and binary items",
+			body:           `{"items": [{"key": "a", "value": {"x": 1}, "ttl": "10s"}, {"key": "b", "data": "AP8=", "content_type": "image/png"}]}`,
+			expectedStatus: http.StatusOK,
+			expectedBody: `{"results": [
+				{"key": "a", "status": "stored", "meta": ` + asJsonStr(common.Meta{Version: 1}) + `},
+				{"key": "b", "status": "error", "error": "` + common.ErrTooLarge.Error() + `"}
+			]}`,
+			msetFunc: func(entries []common.Entry) []common.Result {
+				a, b := entries[0], entries[1]
+				if a.ContentType != common.ContentTypeJSON || a.TTL != 10*time.Second || string(a.Data) != `{"x": 1}` {
+					return nil
+				}
+				if b.ContentType != "image/png" || !bytes.Equal(b.Data, []byte{0x00, 0xff}) {
+					return nil
+				}
+				return []common.Result{
+					{Key: a.Key, Meta: &common.Meta{Version: 1}},
+					{Key: b.Key, Err: common.ErrTooLarge},
+				}
+			},
+		},
+		{
+			name:           "Item without key",
+			body:           `{"items": [{"value": 1}]}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   errJson(errBadJson),
+		},
+		{
+			name:           "Item without value or data",
+			body:           `{"items": [{"key": "a"}]}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   errJson(errBadJson),
+		},
+		{
+			name:           "Invalid ttl",
+			body:           `{"items": [{"key": "a", "value": 1, "ttl": "soon"}]}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   errJson(errBadJson),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockStore := &MockStore{
+				MSetFunc: tt.msetFunc,
+			}
+
+			service := New(mockStore)
+			router := gin.Default()
+			router.POST("/mset", service.MSetHandler)
+
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("POST", "/mset", bytes.NewBufferString(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			assert.JSONEq(t, tt.expectedBody, w.Body.String())
+		})
+	}
+}
@@ -1,33 +1,111 @@
 package rest
 
 import (
+	"encoding/json"
 	"errors"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/johannessarpola/poor-cache-go/internal/common"
 	"github.com/johannessarpola/poor-cache-go/internal/logger"
 )
 
 var (
+	errBadBody  = errors.New("could not read body")
 	errBadJson  = errors.New("invalid json body")
 	errBadQuery = errors.New("invalid query parameters")
 	errInternal = errors.New("internal server error")
 	errNotFound = errors.New("not found")
+	errTooLarge = errors.New("value too large")
+	errBadTTL   = errors.New("ttl must be positive")
+	errBadETag  = errors.New("invalid If-Match header")
+
+	errPrecondition = errors.New("precondition failed")
+	errConflict     = errors.New("write condition not met")
+	errNotInteger   = errors.New("value is not an integer")
+	errWrongType    = errors.New("key holds a different kind of value")
+	errOverflow     = errors.New("increment would overflow")
+	errBadCursor    = errors.New("invalid cursor")
+	errBadScore     = errors.New("score must be a finite number")
+	errOrigin       = errors.New("could not load value from origin")
+
+	errUnknownNamespace = errors.New("unknown namespace")
 )
 
 func newErr(err error) map[string]any {
 	return gin.H{"error": err.Error()}
 }
 
+// storeErr responds with the status matching a store error, hiding anything
+// unexpected behind a 500.
+func storeErr(c *gin.Context, err error) {
+	status, e := storeErrStatus(err)
+	c.JSON(status, newErr(e))
+}
+
+func storeErrStatus(err error) (int, error) {
+	switch {
+	case errors.Is(err, common.ErrNotFound):
+		return http.StatusNotFound, errNotFound
+	case errors.Is(err, common.ErrInvalidTTL):
+		return http.StatusBadRequest, errBadTTL
+	case errors.Is(err, common.ErrExists):
+		return http.StatusConflict, errConflict
+	case errors.Is(err, common.ErrVersionMismatch):
+		return http.StatusPreconditionFailed, errPrecondition
+	case errors.Is(err, common.ErrWrongType):
+		return http.StatusUnprocessableEntity, errWrongType
+	case errors.Is(err, common.ErrNotInteger):
+		return http.StatusUnprocessableEntity, errNotInteger
+	case errors.Is(err, common.ErrOverflow):
+		return http.StatusUnprocessableEntity, errOverflow
+	case errors.Is(err, common.ErrTooLarge):
+		return http.StatusRequestEntityTooLarge, errTooLarge
+	case errors.Is(err, common.ErrInvalidCursor):
+		return http.StatusBadRequest, errBadCursor
+	case errors.Is(err, common.ErrBadScore):
+		return http.StatusBadRequest, errBadScore
+	case errors.Is(err, common.ErrLoad):
+		return http.StatusBadGateway, errOrigin
+	}
+	return http.StatusInternalServerError, errInternal
+}
+
+// SetParams are the query parameters of a write. Without a ttl the store
+// default applies, and a store without one keeps the value forever. Mode makes
+// the write conditional: "nx" only sets missing keys, "xx" only existing ones.
 type SetParams struct {
+	TTL  time.Duration `form:"ttl"`
+	Mode string        `form:"mode" binding:"omitempty,oneof=nx xx"`
+}
+
+// CounterParams are the query parameters of an increment. The ttl only applies
+// when the counter is created.
+type CounterParams struct {
+	By  int64         `form:"by,default=1"`
+	TTL time.Duration `form:"ttl"`
+}
+
+type ExpireParams struct {
 	TTL time.Duration `form:"ttl" binding:"required"`
 }
 
 func (s *Service) SetHandler(c *gin.Context) {
 	key := c.Param("key")
-	var value interface{}
-	if err := c.ShouldBindJSON(&value); err != nil {
+	body, err := c.GetRawData()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, newErr(errBadBody))
+		return
+	}
+
+	contentType := c.GetHeader("Content-Type")
+	if contentType == "" {
+		contentType = common.ContentTypeBinary
+	}
+	if common.IsJSON(contentType) && !json.Valid(body) {
 		c.JSON(http.StatusBadRequest, newErr(errBadJson))
 		return
 	}
@@ -38,36 +116,96 @@ func (s *Service) SetHandler(c *gin.Context) {
 		return
 	}
 
-	if err := s.store.Set(key, value, params.TTL); err != nil {
-		logger.Errorf("Could not set key %s with value %#v", key, value)
-		c.JSON(http.StatusInternalServerError, newErr(errInternal))
+	var meta *common.Meta
+	ifMatch := c.GetHeader("If-Match")
+	switch {
+	case ifMatch == "*":
+		// Any current version matches, as long as there is one.
+		meta, err = s.storeFor(c).SetXX(key, body, contentType, params.TTL)
+		if errors.Is(err, common.ErrNotFound) {
+			err = common.ErrVersionMismatch
+		}
+	case ifMatch != "":
+		version, ok := parseETag(ifMatch)
+		if !ok {
+			c.JSON(http.StatusBadRequest, newErr(errBadETag))
+			return
+		}
+		meta, err = s.storeFor(c).CompareAndSet(key, body, contentType, params.TTL, version)
+	case params.Mode == "nx":
+		meta, err = s.storeFor(c).SetNX(key, body, contentType, params.TTL)
+	case params.Mode == "xx":
+		meta, err = s.storeFor(c).SetXX(key, body, contentType, params.TTL)
+		if errors.Is(err, common.ErrNotFound) {
+			c.JSON(http.StatusConflict, newErr(errConflict))
+			return
+		}
+	default:
+		meta, err = s.storeFor(c).SetBytes(key, body, contentType, params.TTL)
+	}
+	if err != nil {
+		logger.Errorf("Could not set key %s: %s", key, err)
+		storeErr(c, err)
 		return
 	}
 
+	c.Header("ETag", etag(meta.Version))
 	c.JSON(http.StatusCreated, gin.H{"message": "success"})
 }
 
+// GetHandler responds with the stored bytes and their original content type.
+// Metadata is returned in headers so the body stays exactly what was written.
 func (s *Service) GetHandler(c *gin.Context) {
 	key := c.Param("key")
-	var dest any
-	meta, err := s.store.Get(key, &dest)
+	data, meta, err := s.storeFor(c).GetBytes(key)
 	if err != nil {
 		logger.Errorf("Could not get key %s", key)
-		c.JSON(http.StatusInternalServerError, newErr(errInternal))
+		storeErr(c, err)
 		return
 	}
 
-	if dest == nil {
+	if meta == nil {
 		c.JSON(http.StatusNotFound, newErr(errNotFound))
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"meta": meta, "value": dest})
+	setMetaHeaders(c, meta)
+	c.Data(http.StatusOK, meta.ContentType, data)
+}
+
+const (
+	headerCreatedAt  = "X-Created-At"
+	headerModifiedAt = "X-Modified-At"
+	headerAccessedAt = "X-Accessed-At"
+	headerVersion    = "X-Version"
+)
+
+func setMetaHeaders(c *gin.Context, meta *common.Meta) {
+	c.Header(headerCreatedAt, meta.CreatedAt.Format(time.RFC3339Nano))
+	c.Header(headerModifiedAt, meta.ModifiedAt.Format(time.RFC3339Nano))
+	c.Header(headerAccessedAt, meta.AccessedAt.Format(time.RFC3339Nano))
+	c.Header(headerVersion, strconv.FormatUint(meta.Version, 10))
+	c.Header("ETag", etag(meta.Version))
+}
+
+// etag renders an item version as a strong entity tag.
+func etag(version uint64) string {
+	return `"` + strconv.FormatUint(version, 10) + `"`
+}
+
+func parseETag(s string) (uint64, bool) {
+	s = strings.TrimPrefix(strings.TrimSpace(s), "W/")
+	unquoted, err := strconv.Unquote(s)
+	if err != nil {
+		unquoted = s
+	}
+	version, err := strconv.ParseUint(unquoted, 10, 64)
+	return version, err == nil
 }
 
 func (s *Service) DeleteHandler(c *gin.Context) {
 	key := c.Param("key")
-	if err := s.store.Delete(key); err != nil {
+	if err := s.storeFor(c).Delete(key); err != nil {
 		c.JSON(http.StatusInternalServerError, newErr(errInternal))
 		return
 	}
@@ -77,6 +215,85 @@ func (s *Service) DeleteHandler(c *gin.Context) {
 
 func (s *Service) HasHandler(c *gin.Context) {
 	key := c.Param("key")
-	exists := s.store.Has(key)
+	exists := s.storeFor(c).Has(key)
 	c.JSON(http.StatusOK, gin.H{"exists": exists})
 }
+
+// TTLHandler reports the remaining time to live of a key. Keys without an
+// expiration are reported with expires set to false, missing keys with 404.
+func (s *Service) TTLHandler(c *gin.Context) {
+	key := c.Param("key")
+	ttl, ok := s.storeFor(c).TTL(key)
+	if !ok {
+		c.JSON(http.StatusNotFound, newErr(errNotFound))
+		return
+	}
+
+	if ttl == common.NoExpiry {
+		c.JSON(http.StatusOK, gin.H{"expires": false})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"expires": true, "ttl": ttl.String(), "ttl_ms": ttl.Milliseconds()})
+}
+
+func (s *Service) ExpireHandler(c *gin.Context) {
+	key := c.Param("key")
+	params := ExpireParams{}
+	if err := c.ShouldBindQuery(&params); err != nil {
+		c.JSON(http.StatusBadRequest, newErr(errBadQuery))
+		return
+	}
+
+	if err := s.storeFor(c).Expire(key, params.TTL); err != nil {
+		storeErr(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "success"})
+}
+
+func (s *Service) PersistHandler(c *gin.Context) {
+	key := c.Param("key")
+	if err := s.storeFor(c).Persist(key); err != nil {
+		storeErr(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "success"})
+}
+
+func (s *Service) TouchHandler(c *gin.Context) {
+	key := c.Param("key")
+	if err := s.storeFor(c).Touch(key); err != nil {
+		storeErr(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "success"})
+}
+
+func (s *Service) IncrHandler(c *gin.Context) {
+	s.counterHandler(c, s.storeFor(c).Incr)
+}
+
+func (s *Service) DecrHandler(c *gin.Context) {
+	s.counterHandler(c, s.storeFor(c).Decr)
+}
+
+func (s *Service) counterHandler(c *gin.Context, apply func(key string, delta int64, ttl time.Duration) (int64, error)) {
+	key := c.Param("key")
+	params := CounterParams{}
+	if err := c.ShouldBindQuery(&params); err != nil {
+		c.JSON(http.StatusBadRequest, newErr(errBadQuery))
+		return
+	}
+
+	n, err := apply(key, params.By, params.TTL)
+	if err != nil {
+		storeErr(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"value": n})
+}
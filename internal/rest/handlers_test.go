@@ -36,22 +36,39 @@ func TestSetHandler(t *testing.T) {
 		key            string
 		ttl            string
 		body           string
+		contentType    string
 		expectedStatus int
 		expectedBody   string
-		setFunc        func(key string, value any, ttl time.Duration) error
+		setFunc        func(key string, data []byte, contentType string, ttl time.Duration) (*common.Meta, error)
 	}{
 		{
 			name:           "Successful set",
 			key:            "testKey",
 			ttl:            "10s",
 			body:           `{"value": "testValue"}`,
+			contentType:    "application/json",
 			expectedStatus: http.StatusCreated,
 			expectedBody:   `{"message": "success"}`,
-			setFunc: func(key string, value any, ttl time.Duration) error {
-				if key == "testKey" && ttl == 10*time.Second {
-					return nil
+			setFunc: func(key string, data []byte, contentType string, ttl time.Duration) (*common.Meta, error) {
+				if key == "testKey" && ttl == 10*time.Second && string(data) == `{"value": "testValue"}` {
+					return &common.Meta{Version: 1}, nil
 				}
-				return assert.AnError
+				return nil, assert.AnError
+			},
+		},
+		{
+			name:           "Successful binary set",
+			key:            "testKey",
+			ttl:            "10s",
+			body:           "\x00\xffbinary",
+			contentType:    "image/png",
+			expectedStatus: http.StatusCreated,
+			expectedBody:   `{"message": "success"}`,
+			setFunc: func(key string, data []byte, contentType string, ttl time.Duration) (*common.Meta, error) {
+				if contentType == "image/png" && string(data) == "\x00\xffbinary" {
+					return &common.Meta{Version: 1}, nil
+				}
+				return nil, assert.AnError
 			},
 		},
 		{
@@ -59,21 +76,38 @@ func TestSetHandler(t *testing.T) {
 			key:            "testKey",
 			ttl:            "",
 			body:           `{1: 2}`,
+			contentType:    "application/json",
 			expectedStatus: http.StatusBadRequest,
 			expectedBody:   errJson(errBadJson),
-			setFunc: func(key string, value any, ttl time.Duration) error {
-				return nil
+			setFunc: func(key string, data []byte, contentType string, ttl time.Duration) (*common.Meta, error) {
+				return &common.Meta{Version: 1}, nil
 			},
 		},
 		{
-			name:           "Missing TTL parameter",
+			name:           "Missing TTL parameter stores without expiration",
 			key:            "testKey",
 			ttl:            "",
 			body:           `{"value": "testValue"}`,
+			contentType:    "application/json",
+			expectedStatus: http.StatusCreated,
+			expectedBody:   `{"message": "success"}`,
+			setFunc: func(key string, data []byte, contentType string, ttl time.Duration) (*common.Meta, error) {
+				if ttl == 0 {
+					return &common.Meta{Version: 1}, nil
+				}
+				return nil, assert.AnError
+			},
+		},
+		{
+			name:           "Invalid TTL parameter",
+			key:            "testKey",
+			ttl:            "soon",
+			body:           `{"value": "testValue"}`,
+			contentType:    "application/json",
 			expectedStatus: http.StatusBadRequest,
 			expectedBody:   errJson(errBadQuery),
-			setFunc: func(key string, value any, ttl time.Duration) error {
-				return nil
+			setFunc: func(key string, data []byte, contentType string, ttl time.Duration) (*common.Meta, error) {
+				return &common.Meta{Version: 1}, nil
 			},
 		},
 		{
@@ -83,8 +117,8 @@ func TestSetHandler(t *testing.T) {
 			body:           `{"value": "testValue"}`,
 			expectedStatus: http.StatusInternalServerError,
 			expectedBody:   errJson(errInternal),
-			setFunc: func(key string, value any, ttl time.Duration) error {
-				return assert.AnError
+			setFunc: func(key string, data []byte, contentType string, ttl time.Duration) (*common.Meta, error) {
+				return nil, assert.AnError
 			},
 		},
 	}
@@ -100,7 +134,151 @@ func TestSetHandler(t *testing.T) {
 			router.POST("/set/:key", service.SetHandler)
 
 			w := httptest.NewRecorder()
-			req, _ := http.NewRequest("POST", "/set/"+tt.key+"?ttl="+tt.ttl, bytes.NewBuffer([]byte(tt.body)))
+			path := "/set/" + tt.key
+			if tt.ttl != "" {
+				path += "?ttl=" + tt.ttl
+			}
+			req, _ := http.NewRequest("POST", path, bytes.NewBuffer([]byte(tt.body)))
+			req.Header.Set("Content-Type", tt.contentType)
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			assert.JSONEq(t, tt.expectedBody, w.Body.String())
+		})
+	}
+}
+
+func TestSetHandlerIfMatch(t *testing.T) {
+	tests := []struct {
+		name           string
+		ifMatch        string
+		expectedStatus int
+		expectedBody   string
+		expectedETag   string
+		casFunc        func(key string, data []byte, contentType string, ttl time.Duration, version uint64) (*common.Meta, error)
+	}{
+		{
+			name:           "Matching version",
+			ifMatch:        `"7"`,
+			expectedStatus: http.StatusCreated,
+			expectedBody:   `{"message": "success"}`,
+			expectedETag:   `"8"`,
+			casFunc: func(key string, data []byte, contentType string, ttl time.Duration, version uint64) (*common.Meta, error) {
+				if version == 7 {
+					return &common.Meta{Version: 8}, nil
+				}
+				return nil, assert.AnError
+			},
+		},
+		{
+			name:           "Version mismatch",
+			ifMatch:        `"6"`,
+			expectedStatus: http.StatusPreconditionFailed,
+			expectedBody:   errJson(errPrecondition),
+			casFunc: func(key string, data []byte, contentType string, ttl time.Duration, version uint64) (*common.Meta, error) {
+				return nil, common.ErrVersionMismatch
+			},
+		},
+		{
+			name:           "Invalid etag",
+			ifMatch:        `"abc"`,
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   errJson(errBadETag),
+			casFunc: func(key string, data []byte, contentType string, ttl time.Duration, version uint64) (*common.Meta, error) {
+				return nil, assert.AnError
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockStore := &MockStore{
+				CASFunc: tt.casFunc,
+			}
+
+			service := New(mockStore)
+			router := gin.Default()
+			router.POST("/set/:key", service.SetHandler)
+
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("POST", "/set/testKey", bytes.NewBuffer([]byte(`{"value": 1}`)))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("If-Match", tt.ifMatch)
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			assert.JSONEq(t, tt.expectedBody, w.Body.String())
+			assert.Equal(t, tt.expectedETag, w.Header().Get("ETag"))
+		})
+	}
+}
+
+func TestSetHandlerMode(t *testing.T) {
+	tests := []struct {
+		name           string
+		mode           string
+		expectedStatus int
+		expectedBody   string
+		setNXFunc      func(key string, data []byte, contentType string, ttl time.Duration) (*common.Meta, error)
+		setXXFunc      func(key string, data []byte, contentType string, ttl time.Duration) (*common.Meta, error)
+	}{
+		{
+			name:           "Set if absent",
+			mode:           "nx",
+			expectedStatus: http.StatusCreated,
+			expectedBody:   `{"message": "success"}`,
+			setNXFunc: func(key string, data []byte, contentType string, ttl time.Duration) (*common.Meta, error) {
+				return &common.Meta{Version: 1}, nil
+			},
+		},
+		{
+			name:           "Set if absent on existing key",
+			mode:           "nx",
+			expectedStatus: http.StatusConflict,
+			expectedBody:   errJson(errConflict),
+			setNXFunc: func(key string, data []byte, contentType string, ttl time.Duration) (*common.Meta, error) {
+				return nil, common.ErrExists
+			},
+		},
+		{
+			name:           "Set if present",
+			mode:           "xx",
+			expectedStatus: http.StatusCreated,
+			expectedBody:   `{"message": "success"}`,
+			setXXFunc: func(key string, data []byte, contentType string, ttl time.Duration) (*common.Meta, error) {
+				return &common.Meta{Version: 2}, nil
+			},
+		},
+		{
+			name:           "Set if present on missing key",
+			mode:           "xx",
+			expectedStatus: http.StatusConflict,
+			expectedBody:   errJson(errConflict),
+			setXXFunc: func(key string, data []byte, contentType string, ttl time.Duration) (*common.Meta, error) {
+				return nil, common.ErrNotFound
+			},
+		},
+		{
+			name:           "Unknown mode",
+			mode:           "maybe",
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   errJson(errBadQuery),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockStore := &MockStore{
+				SetNXFunc: tt.setNXFunc,
+				SetXXFunc: tt.setXXFunc,
+			}
+
+			service := New(mockStore)
+			router := gin.Default()
+			router.POST("/set/:key", service.SetHandler)
+
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("POST", "/set/testKey?mode="+tt.mode, bytes.NewBuffer([]byte(`{"value": 1}`)))
 			req.Header.Set("Content-Type", "application/json")
 			router.ServeHTTP(w, req)
 
@@ -112,48 +290,80 @@ func TestSetHandler(t *testing.T) {
 
 func TestGetHandler(t *testing.T) {
 	start := time.Now()
-	data1 := map[string]any{"field": "value"}
+	data1 := []byte(`{"field": "value", "big": 12345678901234567890}`)
 	meta1 := common.Meta{
-		CreatedAt:  start.Add(-1 * time.Minute),
-		ModifiedAt: start,
+		CreatedAt:   start.Add(-1 * time.Minute),
+		ModifiedAt:  start,
+		AccessedAt:  start,
+		Version:     3,
+		ContentType: "application/json",
+	}
+	data2 := []byte{0x89, 'P', 'N', 'G', 0x00}
+	meta2 := common.Meta{
+		CreatedAt:   start,
+		ModifiedAt:  start,
+		ContentType: "image/png",
 	}
 
 	tests := []struct {
-		name           string
-		key            string
-		expectedStatus int
-		expectedBody   string
-		getFunc        func(key string, dest any) (*common.Meta, error)
+		name                string
+		key                 string
+		expectedStatus      int
+		expectedBody        string
+		expectedContentType string
+		getFunc             func(key string) ([]byte, *common.Meta, error)
 	}{
 		{
-			name:           "Successful get",
-			key:            "testKey",
-			expectedStatus: http.StatusOK,
-			expectedBody:   asJsonStr(map[string]any{"meta": meta1, "value": data1}),
-			getFunc: func(key string, dest any) (*common.Meta, error) {
+			name:                "Successful get",
+			key:                 "testKey",
+			expectedStatus:      http.StatusOK,
+			expectedBody:        string(data1),
+			expectedContentType: "application/json",
+			getFunc: func(key string) ([]byte, *common.Meta, error) {
 				if key == "testKey" {
-					json.Unmarshal(asJson(data1), &dest)
-					return &meta1, nil
+					return data1, &meta1, nil
 				}
-				return nil, assert.AnError
+				return nil, nil, assert.AnError
 			},
 		},
 		{
-			name:           "Key not found",
-			key:            "testKey",
-			expectedStatus: http.StatusNotFound,
-			expectedBody:   errJson(errNotFound),
-			getFunc: func(key string, dest any) (*common.Meta, error) {
-				return nil, nil
+			name:                "Successful binary get",
+			key:                 "testKey",
+			expectedStatus:      http.StatusOK,
+			expectedBody:        string(data2),
+			expectedContentType: "image/png",
+			getFunc: func(key string) ([]byte, *common.Meta, error) {
+				return data2, &meta2, nil
 			},
 		},
 		{
-			name:           "Store get error",
-			key:            "testKey",
-			expectedStatus: http.StatusInternalServerError,
-			expectedBody:   errJson(errInternal),
-			getFunc: func(key string, dest any) (*common.Meta, error) {
-				return nil, assert.AnError
+			name:                "Key not found",
+			key:                 "testKey",
+			expectedStatus:      http.StatusNotFound,
+			expectedBody:        errJson(errNotFound),
+			expectedContentType: "application/json; charset=utf-8",
+			getFunc: func(key string) ([]byte, *common.Meta, error) {
+				return nil, nil, nil
+			},
+		},
+		{
+			name:                "Store get error",
+			key:                 "testKey",
+			expectedStatus:      http.StatusInternalServerError,
+			expectedBody:        errJson(errInternal),
+			expectedContentType: "application/json; charset=utf-8",
+			getFunc: func(key string) ([]byte, *common.Meta, error) {
+				return nil, nil, assert.AnError
+			},
+		},
+		{
+			name:                "Origin load error",
+			key:                 "testKey",
+			expectedStatus:      http.StatusBadGateway,
+			expectedBody:        errJson(errOrigin),
+			expectedContentType: "application/json; charset=utf-8",
+			getFunc: func(key string) ([]byte, *common.Meta, error) {
+				return nil, nil, fmt.Errorf("%w: origin responded 500", common.ErrLoad)
 			},
 		},
 	}
@@ -172,10 +382,14 @@ func TestGetHandler(t *testing.T) {
 			req, _ := http.NewRequest("GET", "/get/"+tt.key, nil)
 			router.ServeHTTP(w, req)
 
-			body := w.Body.String()
-			fmt.Println(body)
 			assert.Equal(t, tt.expectedStatus, w.Code)
-			assert.JSONEq(t, tt.expectedBody, body)
+			assert.Equal(t, tt.expectedBody, w.Body.String())
+			assert.Equal(t, tt.expectedContentType, w.Header().Get("Content-Type"))
+			if tt.name == "Successful get" {
+				assert.Equal(t, "3", w.Header().Get(headerVersion))
+				assert.Equal(t, `"3"`, w.Header().Get("ETag"))
+				assert.Equal(t, meta1.CreatedAt.Format(time.RFC3339Nano), w.Header().Get(headerCreatedAt))
+			}
 		})
 	}
 }
@@ -278,3 +492,263 @@ func TestHasHandler(t *testing.T) {
 		})
 	}
 }
+
+func TestTTLHandler(t *testing.T) {
+	tests := []struct {
+		name           string
+		key            string
+		expectedStatus int
+		expectedBody   string
+		ttlFunc        func(key string) (time.Duration, bool)
+	}{
+		{
+			name:           "Key with expiration",
+			key:            "testKey",
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"expires": true, "ttl": "1m30s", "ttl_ms": 90000}`,
+			ttlFunc: func(key string) (time.Duration, bool) {
+				return 90 * time.Second, key == "testKey"
+			},
+		},
+		{
+			name:           "Key without expiration",
+			key:            "testKey",
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"expires": false}`,
+			ttlFunc: func(key string) (time.Duration, bool) {
+				return common.NoExpiry, true
+			},
+		},
+		{
+			name:           "Key does not exist",
+			key:            "testKey",
+			expectedStatus: http.StatusNotFound,
+			expectedBody:   errJson(errNotFound),
+			ttlFunc: func(key string) (time.Duration, bool) {
+				return 0, false
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockStore := &MockStore{
+				TTLFunc: tt.ttlFunc,
+			}
+
+			service := New(mockStore)
+			router := gin.Default()
+			router.GET("/ttl/:key", service.TTLHandler)
+
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("GET", "/ttl/"+tt.key, nil)
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			assert.JSONEq(t, tt.expectedBody, w.Body.String())
+		})
+	}
+}
+
+func TestExpireHandler(t *testing.T) {
+	tests := []struct {
+		name           string
+		key            string
+		ttl            string
+		expectedStatus int
+		expectedBody   string
+		expireFunc     func(key string, ttl time.Duration) error
+	}{
+		{
+			name:           "Successful expire",
+			key:            "testKey",
+			ttl:            "1m",
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"message": "success"}`,
+			expireFunc: func(key string, ttl time.Duration) error {
+				if key == "testKey" && ttl == time.Minute {
+					return nil
+				}
+				return assert.AnError
+			},
+		},
+		{
+			name:           "Missing TTL parameter",
+			key:            "testKey",
+			ttl:            "",
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   errJson(errBadQuery),
+			expireFunc: func(key string, ttl time.Duration) error {
+				return nil
+			},
+		},
+		{
+			name:           "Key not found",
+			key:            "testKey",
+			ttl:            "1m",
+			expectedStatus: http.StatusNotFound,
+			expectedBody:   errJson(errNotFound),
+			expireFunc: func(key string, ttl time.Duration) error {
+				return common.ErrNotFound
+			},
+		},
+		{
+			name:           "Negative TTL",
+			key:            "testKey",
+			ttl:            "-1m",
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   errJson(errBadTTL),
+			expireFunc: func(key string, ttl time.Duration) error {
+				return common.ErrInvalidTTL
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockStore := &MockStore{
+				ExpireFunc: tt.expireFunc,
+			}
+
+			service := New(mockStore)
+			router := gin.Default()
+			router.POST("/expire/:key", service.ExpireHandler)
+
+			w := httptest.NewRecorder()
+			path := "/expire/" + tt.key
+			if tt.ttl != "" {
+				path += "?ttl=" + tt.ttl
+			}
+			req, _ := http.NewRequest("POST", path, nil)
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			assert.JSONEq(t, tt.expectedBody, w.Body.String())
+		})
+	}
+}
+
+func TestPersistAndTouchHandlers(t *testing.T) {
+	tests := []struct {
+		name           string
+		path           string
+		expectedStatus int
+		expectedBody   string
+		err            error
+	}{
+		{
+			name:           "Successful persist",
+			path:           "/persist/testKey",
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"message": "success"}`,
+		},
+		{
+			name:           "Persist missing key",
+			path:           "/persist/testKey",
+			expectedStatus: http.StatusNotFound,
+			expectedBody:   errJson(errNotFound),
+			err:            common.ErrNotFound,
+		},
+		{
+			name:           "Successful touch",
+			path:           "/touch/testKey",
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"message": "success"}`,
+		},
+		{
+			name:           "Touch store error",
+			path:           "/touch/testKey",
+			expectedStatus: http.StatusInternalServerError,
+			expectedBody:   errJson(errInternal),
+			err:            assert.AnError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockStore := &MockStore{
+				PersistFunc: func(key string) error { return tt.err },
+				TouchFunc:   func(key string) error { return tt.err },
+			}
+
+			service := New(mockStore)
+			router := gin.Default()
+			router.POST("/persist/:key", service.PersistHandler)
+			router.POST("/touch/:key", service.TouchHandler)
+
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("POST", tt.path, nil)
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			assert.JSONEq(t, tt.expectedBody, w.Body.String())
+		})
+	}
+}
+
+func TestCounterHandlers(t *testing.T) {
+	tests := []struct {
+		name           string
+		path           string
+		expectedStatus int
+		expectedBody   string
+		incrFunc       func(key string, delta int64, ttl time.Duration) (int64, error)
+	}{
+		{
+			name:           "Increment by default",
+			path:           "/incr/testKey",
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"value": 1}`,
+			incrFunc: func(key string, delta int64, ttl time.Duration) (int64, error) {
+				return delta, nil
+			},
+		},
+		{
+			name:           "Decrement with ttl",
+			path:           "/decr/testKey?by=5&ttl=1m",
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"value": -5}`,
+			incrFunc: func(key string, delta int64, ttl time.Duration) (int64, error) {
+				if ttl != time.Minute {
+					return 0, assert.AnError
+				}
+				return delta, nil
+			},
+		},
+		{
+			name:           "Not an integer",
+			path:           "/incr/testKey",
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   errJson(errNotInteger),
+			incrFunc: func(key string, delta int64, ttl time.Duration) (int64, error) {
+				return 0, common.ErrNotInteger
+			},
+		},
+		{
+			name:           "Invalid delta",
+			path:           "/incr/testKey?by=lots",
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   errJson(errBadQuery),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockStore := &MockStore{
+				IncrFunc: tt.incrFunc,
+			}
+
+			service := New(mockStore)
+			router := gin.Default()
+			router.POST("/incr/:key", service.IncrHandler)
+			router.POST("/decr/:key", service.DecrHandler)
+
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("POST", tt.path, nil)
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			assert.JSONEq(t, tt.expectedBody, w.Body.String())
+		})
+	}
+}
@@ -0,0 +1,94 @@
+package rest
+
+import (
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// HashIncrParams are the query parameters of a field increment.
+type HashIncrParams struct {
+	By int64 `form:"by,default=1"`
+}
+
+// HGetAllHandler responds with all fields of a hash.
+func (s *Service) HGetAllHandler(c *gin.Context) {
+	fields, err := s.storeFor(c).HGetAll(c.Param("key"))
+	if err != nil {
+		storeErr(c, err)
+		return
+	}
+	if fields == nil {
+		c.JSON(http.StatusNotFound, newErr(errNotFound))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"fields": fields})
+}
+
+// HSetAllHandler sets the fields of a hash from a JSON object of strings.
+func (s *Service) HSetAllHandler(c *gin.Context) {
+	var fields map[string]string
+	if err := c.ShouldBindJSON(&fields); err != nil {
+		c.JSON(http.StatusBadRequest, newErr(errBadJson))
+		return
+	}
+	s.hset(c, fields)
+}
+
+// HSetHandler sets a single field of a hash to the request body.
+func (s *Service) HSetHandler(c *gin.Context) {
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, newErr(errBadBody))
+		return
+	}
+	s.hset(c, map[string]string{c.Param("field"): string(body)})
+}
+
+func (s *Service) hset(c *gin.Context, fields map[string]string) {
+	n, err := s.storeFor(c).HSet(c.Param("key"), fields)
+	if err != nil {
+		storeErr(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"added": n})
+}
+
+// HGetHandler responds with a single field of a hash.
+func (s *Service) HGetHandler(c *gin.Context) {
+	value, ok, err := s.storeFor(c).HGet(c.Param("key"), c.Param("field"))
+	if err != nil {
+		storeErr(c, err)
+		return
+	}
+	if !ok {
+		c.JSON(http.StatusNotFound, newErr(errNotFound))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"value": value})
+}
+
+func (s *Service) HDelHandler(c *gin.Context) {
+	n, err := s.storeFor(c).HDel(c.Param("key"), c.Param("field"))
+	if err != nil {
+		storeErr(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"deleted": n})
+}
+
+func (s *Service) HIncrByHandler(c *gin.Context) {
+	params := HashIncrParams{}
+	if err := c.ShouldBindQuery(&params); err != nil {
+		c.JSON(http.StatusBadRequest, newErr(errBadQuery))
+		return
+	}
+
+	n, err := s.storeFor(c).HIncrBy(c.Param("key"), c.Param("field"), params.By)
+	if err != nil {
+		storeErr(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"value": n})
+}
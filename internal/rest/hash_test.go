@@ -0,0 +1,136 @@
+package rest
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/johannessarpola/poor-cache-go/internal/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHashHandlers(t *testing.T) {
+	tests := []struct {
+		name           string
+		method         string
+		path           string
+		body           string
+		expectedStatus int
+		expectedBody   string
+		store          *MockStore
+	}{
+		{
+			name:           "Get all fields",
+			method:         "GET",
+			path:           "/hash/user",
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"fields": {"name": "ada"}}`,
+			store: &MockStore{HGetAllFunc: func(key string) (map[string]string, error) {
+				return map[string]string{"name": "ada"}, nil
+			}},
+		},
+		{
+			name:           "Get all of missing key",
+			method:         "GET",
+			path:           "/hash/user",
+			expectedStatus: http.StatusNotFound,
+			expectedBody:   errJson(errNotFound),
+			store: &MockStore{HGetAllFunc: func(key string) (map[string]string, error) {
+				return nil, nil
+			}},
+		},
+		{
+			name:           "Set fields from object",
+			method:         "POST",
+			path:           "/hash/user",
+			body:           `{"name": "ada", "lang": "en"}`,
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"added": 2}`,
+			store: &MockStore{HSetFunc: func(key string, fields map[string]string) (int, error) {
+				return len(fields), nil
+			}},
+		},
+		{
+			name:           "Set single field from body",
+			method:         "POST",
+			path:           "/hash/user/name",
+			body:           "ada",
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"added": 1}`,
+			store: &MockStore{HSetFunc: func(key string, fields map[string]string) (int, error) {
+				if fields["name"] != "ada" {
+					return 0, assert.AnError
+				}
+				return 1, nil
+			}},
+		},
+		{
+			name:           "Set on plain key",
+			method:         "POST",
+			path:           "/hash/plain/name",
+			body:           "ada",
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   errJson(errWrongType),
+			store: &MockStore{HSetFunc: func(key string, fields map[string]string) (int, error) {
+				return 0, common.ErrWrongType
+			}},
+		},
+		{
+			name:           "Get field",
+			method:         "GET",
+			path:           "/hash/user/name",
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"value": "ada"}`,
+			store: &MockStore{HGetFunc: func(key, field string) (string, bool, error) {
+				return "ada", true, nil
+			}},
+		},
+		{
+			name:           "Get missing field",
+			method:         "GET",
+			path:           "/hash/user/age",
+			expectedStatus: http.StatusNotFound,
+			expectedBody:   errJson(errNotFound),
+			store: &MockStore{HGetFunc: func(key, field string) (string, bool, error) {
+				return "", false, nil
+			}},
+		},
+		{
+			name:           "Delete field",
+			method:         "DELETE",
+			path:           "/hash/user/name",
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"deleted": 1}`,
+			store: &MockStore{HDelFunc: func(key string, fields ...string) (int, error) {
+				return len(fields), nil
+			}},
+		},
+		{
+			name:           "Increment field",
+			method:         "POST",
+			path:           "/hash/user/visits/incr?by=5",
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"value": 5}`,
+			store: &MockStore{HIncrByFunc: func(key, field string, delta int64) (int64, error) {
+				return delta, nil
+			}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := New(tt.store)
+			router := gin.Default()
+			SetupRouter(router.Group(""), service)
+
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest(tt.method, tt.path, bytes.NewBufferString(tt.body))
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			assert.JSONEq(t, tt.expectedBody, w.Body.String())
+		})
+	}
+}
@@ -0,0 +1,65 @@
+package rest
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ScanParams are the query parameters of a key listing. Match is a glob
+// pattern, Cursor the cursor returned by the previous page.
+type ScanParams struct {
+	Match  string `form:"match"`
+	Cursor string `form:"cursor"`
+	Count  int    `form:"count" binding:"omitempty,min=1"`
+}
+
+// KeysHandler lists one page of keys. An empty cursor in the response means
+// the listing is complete.
+func (s *Service) KeysHandler(c *gin.Context) {
+	params := ScanParams{}
+	if err := c.ShouldBindQuery(&params); err != nil {
+		c.JSON(http.StatusBadRequest, newErr(errBadQuery))
+		return
+	}
+
+	keys, cursor, err := s.storeFor(c).Scan(params.Match, params.Cursor, params.Count)
+	if err != nil {
+		storeErr(c, err)
+		return
+	}
+	if keys == nil {
+		keys = []string{}
+	}
+	c.JSON(http.StatusOK, gin.H{"keys": keys, "cursor": cursor})
+}
+
+// DeleteKeysParams select the keys of a bulk delete, either by prefix or by
+// glob pattern. Exactly one of them must be given.
+type DeleteKeysParams struct {
+	Prefix string `form:"prefix" binding:"required_without=Match,excluded_with=Match"`
+	Match  string `form:"match" binding:"required_without=Prefix"`
+}
+
+// DeleteKeysHandler removes every key selected by prefix or pattern and
+// responds with the number of keys removed.
+func (s *Service) DeleteKeysHandler(c *gin.Context) {
+	params := DeleteKeysParams{}
+	if err := c.ShouldBindQuery(&params); err != nil {
+		c.JSON(http.StatusBadRequest, newErr(errBadQuery))
+		return
+	}
+
+	var n int
+	var err error
+	if params.Prefix != "" {
+		n, err = s.storeFor(c).DeletePrefix(params.Prefix)
+	} else {
+		n, err = s.storeFor(c).DeleteMatching(params.Match)
+	}
+	if err != nil {
+		storeErr(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"deleted": n})
+}
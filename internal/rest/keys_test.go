@@ -0,0 +1,145 @@
+package rest
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/johannessarpola/poor-cache-go/internal/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKeysHandler(t *testing.T) {
+	tests := []struct {
+		name           string
+		query          string
+		expectedStatus int
+		expectedBody   string
+		scanFunc       func(pattern, cursor string, count int) ([]string, string, error)
+	}{
+		{
+			name:           "First page",
+			query:          "?match=user:*&count=2",
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"keys": ["user:1", "user:2"], "cursor": "next"}`,
+			scanFunc: func(pattern, cursor string, count int) ([]string, string, error) {
+				if pattern != "user:*" || cursor != "" || count != 2 {
+					return nil, "", assert.AnError
+				}
+				return []string{"user:1", "user:2"}, "next", nil
+			},
+		},
+		{
+			name:           "Last empty page",
+			query:          "?cursor=next",
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"keys": [], "cursor": ""}`,
+			scanFunc: func(pattern, cursor string, count int) ([]string, string, error) {
+				return nil, "", nil
+			},
+		},
+		{
+			name:           "Invalid cursor",
+			query:          "?cursor=garbage",
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   errJson(errBadCursor),
+			scanFunc: func(pattern, cursor string, count int) ([]string, string, error) {
+				return nil, "", common.ErrInvalidCursor
+			},
+		},
+		{
+			name:           "Invalid count",
+			query:          "?count=-1",
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   errJson(errBadQuery),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockStore := &MockStore{
+				ScanFunc: tt.scanFunc,
+			}
+
+			service := New(mockStore)
+			router := gin.Default()
+			router.GET("/keys", service.KeysHandler)
+
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("GET", "/keys"+tt.query, nil)
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			assert.JSONEq(t, tt.expectedBody, w.Body.String())
+		})
+	}
+}
+
+func TestDeleteKeysHandler(t *testing.T) {
+	tests := []struct {
+		name               string
+		query              string
+		expectedStatus     int
+		expectedBody       string
+		deletePrefixFunc   func(prefix string) (int, error)
+		deleteMatchingFunc func(pattern string) (int, error)
+	}{
+		{
+			name:           "By prefix",
+			query:          "?prefix=product:",
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"deleted": 3}`,
+			deletePrefixFunc: func(prefix string) (int, error) {
+				if prefix != "product:" {
+					return 0, assert.AnError
+				}
+				return 3, nil
+			},
+		},
+		{
+			name:           "By pattern",
+			query:          "?match=session:*:token",
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"deleted": 2}`,
+			deleteMatchingFunc: func(pattern string) (int, error) {
+				if pattern != "session:*:token" {
+					return 0, assert.AnError
+				}
+				return 2, nil
+			},
+		},
+		{
+			name:           "Neither prefix nor pattern",
+			query:          "",
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   errJson(errBadQuery),
+		},
+		{
+			name:           "Both prefix and pattern",
+			query:          "?prefix=a&match=b*",
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   errJson(errBadQuery),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockStore := &MockStore{
+				DeletePrefixFunc:   tt.deletePrefixFunc,
+				DeleteMatchingFunc: tt.deleteMatchingFunc,
+			}
+
+			service := New(mockStore)
+			router := gin.Default()
+			router.DELETE("/keys", service.DeleteKeysHandler)
+
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("DELETE", "/keys"+tt.query, nil)
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			assert.JSONEq(t, tt.expectedBody, w.Body.String())
+		})
+	}
+}
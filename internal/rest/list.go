@@ -0,0 +1,117 @@
+package rest
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxPopTimeout caps how long a blocking pop holds its request open.
+const maxPopTimeout = time.Minute
+
+// PopParams are the query parameters of a pop. With a timeout the pop waits
+// up to that long for a value to be pushed.
+type PopParams struct {
+	Timeout time.Duration `form:"timeout" binding:"min=0"`
+}
+
+// RangeParams select the values of a list range, both indexes inclusive.
+// Negative indexes count from the end.
+type RangeParams struct {
+	Start int `form:"start,default=0"`
+	Stop  int `form:"stop,default=-1"`
+}
+
+func (s *Service) LPushHandler(c *gin.Context) {
+	s.pushHandler(c, s.storeFor(c).LPush)
+}
+
+func (s *Service) RPushHandler(c *gin.Context) {
+	s.pushHandler(c, s.storeFor(c).RPush)
+}
+
+// pushHandler pushes the values of a JSON array of strings and responds with
+// the new length of the list.
+func (s *Service) pushHandler(c *gin.Context, push func(key string, values ...string) (int, error)) {
+	var values []string
+	if err := c.ShouldBindJSON(&values); err != nil || len(values) == 0 {
+		c.JSON(http.StatusBadRequest, newErr(errBadJson))
+		return
+	}
+
+	n, err := push(c.Param("key"), values...)
+	if err != nil {
+		storeErr(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"length": n})
+}
+
+func (s *Service) LPopHandler(c *gin.Context) {
+	st := s.storeFor(c)
+	s.popHandler(c, st.LPop, st.BLPop)
+}
+
+func (s *Service) RPopHandler(c *gin.Context) {
+	st := s.storeFor(c)
+	s.popHandler(c, st.RPop, st.BRPop)
+}
+
+// popHandler pops a value, long-polling for up to the requested timeout when
+// the list is empty. It responds with 404 when no value arrived.
+func (s *Service) popHandler(c *gin.Context, pop func(key string) (string, bool, error), bpop func(ctx context.Context, key string, timeout time.Duration) (string, bool, error)) {
+	params := PopParams{}
+	if err := c.ShouldBindQuery(&params); err != nil {
+		c.JSON(http.StatusBadRequest, newErr(errBadQuery))
+		return
+	}
+
+	key := c.Param("key")
+	var value string
+	var ok bool
+	var err error
+	if params.Timeout > 0 {
+		value, ok, err = bpop(c.Request.Context(), key, min(params.Timeout, maxPopTimeout))
+	} else {
+		value, ok, err = pop(key)
+	}
+	if err != nil {
+		storeErr(c, err)
+		return
+	}
+	if !ok {
+		c.JSON(http.StatusNotFound, newErr(errNotFound))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"value": value})
+}
+
+func (s *Service) LRangeHandler(c *gin.Context) {
+	params := RangeParams{}
+	if err := c.ShouldBindQuery(&params); err != nil {
+		c.JSON(http.StatusBadRequest, newErr(errBadQuery))
+		return
+	}
+
+	values, err := s.storeFor(c).LRange(c.Param("key"), params.Start, params.Stop)
+	if err != nil {
+		storeErr(c, err)
+		return
+	}
+	if values == nil {
+		c.JSON(http.StatusNotFound, newErr(errNotFound))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"values": values})
+}
+
+func (s *Service) LLenHandler(c *gin.Context) {
+	n, err := s.storeFor(c).LLen(c.Param("key"))
+	if err != nil {
+		storeErr(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"length": n})
+}
@@ -0,0 +1,123 @@
+package rest
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestListHandlers(t *testing.T) {
+	tests := []struct {
+		name           string
+		method         string
+		path           string
+		body           string
+		expectedStatus int
+		expectedBody   string
+		store          *MockStore
+	}{
+		{
+			name:           "Push to tail",
+			method:         "POST",
+			path:           "/list/jobs/rpush",
+			body:           `["a", "b"]`,
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"length": 2}`,
+			store: &MockStore{PushFunc: func(key string, head bool, values ...string) (int, error) {
+				if head {
+					return 0, assert.AnError
+				}
+				return len(values), nil
+			}},
+		},
+		{
+			name:           "Push nothing",
+			method:         "POST",
+			path:           "/list/jobs/lpush",
+			body:           `[]`,
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   errJson(errBadJson),
+		},
+		{
+			name:           "Pop from head",
+			method:         "POST",
+			path:           "/list/jobs/lpop",
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"value": "a"}`,
+			store: &MockStore{PopFunc: func(key string, head bool) (string, bool, error) {
+				return "a", head, nil
+			}},
+		},
+		{
+			name:           "Pop from empty list",
+			method:         "POST",
+			path:           "/list/jobs/rpop",
+			expectedStatus: http.StatusNotFound,
+			expectedBody:   errJson(errNotFound),
+			store: &MockStore{PopFunc: func(key string, head bool) (string, bool, error) {
+				return "", false, nil
+			}},
+		},
+		{
+			name:           "Blocking pop is capped",
+			method:         "POST",
+			path:           "/list/jobs/rpop?timeout=1h",
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"value": "b"}`,
+			store: &MockStore{BPopFunc: func(ctx context.Context, key string, head bool, timeout time.Duration) (string, bool, error) {
+				if head || timeout != maxPopTimeout {
+					return "", false, assert.AnError
+				}
+				return "b", true, nil
+			}},
+		},
+		{
+			name:           "Range with defaults",
+			method:         "GET",
+			path:           "/list/jobs",
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"values": ["a", "b"]}`,
+			store: &MockStore{LRangeFunc: func(key string, start, stop int) ([]string, error) {
+				if start != 0 || stop != -1 {
+					return nil, assert.AnError
+				}
+				return []string{"a", "b"}, nil
+			}},
+		},
+		{
+			name:           "Length",
+			method:         "GET",
+			path:           "/list/jobs/len",
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"length": 3}`,
+			store: &MockStore{LLenFunc: func(key string) (int, error) {
+				return 3, nil
+			}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := tt.store
+			if store == nil {
+				store = &MockStore{}
+			}
+			service := New(store)
+			router := gin.Default()
+			SetupRouter(router.Group(""), service)
+
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest(tt.method, tt.path, bytes.NewBufferString(tt.body))
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			assert.JSONEq(t, tt.expectedBody, w.Body.String())
+		})
+	}
+}
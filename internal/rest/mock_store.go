@@ -1,24 +1,77 @@
 package rest
 
 import (
+	"context"
+	"maps"
+	"slices"
 	"time"
 
 	"github.com/johannessarpola/poor-cache-go/internal/common"
 )
 
 type MockStore struct {
-	SetFunc    func(key string, value any, ttl time.Duration) error
-	GetFunc    func(key string, dest any) (*common.Meta, error)
-	DeleteFunc func(key string) error
-	HasFunc    func(key string) bool
+	SetFunc            func(key string, data []byte, contentType string, ttl time.Duration) (*common.Meta, error)
+	CASFunc            func(key string, data []byte, contentType string, ttl time.Duration, version uint64) (*common.Meta, error)
+	SetNXFunc          func(key string, data []byte, contentType string, ttl time.Duration) (*common.Meta, error)
+	SetXXFunc          func(key string, data []byte, contentType string, ttl time.Duration) (*common.Meta, error)
+	GetFunc            func(key string) ([]byte, *common.Meta, error)
+	DeleteFunc         func(key string) error
+	HasFunc            func(key string) bool
+	TTLFunc            func(key string) (time.Duration, bool)
+	ExpireFunc         func(key string, ttl time.Duration) error
+	PersistFunc        func(key string) error
+	TouchFunc          func(key string) error
+	IncrFunc           func(key string, delta int64, ttl time.Duration) (int64, error)
+	MGetFunc           func(keys []string) []common.Result
+	MSetFunc           func(entries []common.Entry) []common.Result
+	TxFunc             func(ops []common.TxOp) ([]common.Result, error)
+	ScanFunc           func(pattern, cursor string, count int) ([]string, string, error)
+	DeletePrefixFunc   func(prefix string) (int, error)
+	DeleteMatchingFunc func(pattern string) (int, error)
+	FlushFunc          func() (int, error)
+	HSetFunc           func(key string, fields map[string]string) (int, error)
+	HGetFunc           func(key, field string) (string, bool, error)
+	HGetAllFunc        func(key string) (map[string]string, error)
+	HDelFunc           func(key string, fields ...string) (int, error)
+	HIncrByFunc        func(key, field string, delta int64) (int64, error)
+	PushFunc           func(key string, head bool, values ...string) (int, error)
+	PopFunc            func(key string, head bool) (string, bool, error)
+	BPopFunc           func(ctx context.Context, key string, head bool, timeout time.Duration) (string, bool, error)
+	LRangeFunc         func(key string, start, stop int) ([]string, error)
+	LLenFunc           func(key string) (int, error)
+	ZAddFunc           func(key string, members ...common.ZMember) (int, error)
+	ZRemFunc           func(key string, members ...string) (int, error)
+	ZScoreFunc         func(key, member string) (float64, bool, error)
+	ZRankFunc          func(key, member string) (int, bool, error)
+	ZRangeFunc         func(key string, minScore, maxScore float64, limit int) ([]common.ZMember, error)
+	ZRemRangeFunc      func(key string, minScore, maxScore float64) (int, error)
+	SAddFunc           func(key string, members ...string) (int, error)
+	SRemFunc           func(key string, members ...string) (int, error)
+	SIsMemberFunc      func(key, member string) (bool, error)
+	SMembersFunc       func(key string) ([]string, error)
+	SCardFunc          func(key string) (int, error)
+	SetOpFunc          func(op string, keys ...string) ([]string, error)
+	WatchFunc          func(ctx context.Context, prefix string) <-chan common.Event
 }
 
-func (m *MockStore) Set(key string, value any, ttl time.Duration) error {
-	return m.SetFunc(key, value, ttl)
+func (m *MockStore) SetBytes(key string, data []byte, contentType string, ttl time.Duration) (*common.Meta, error) {
+	return m.SetFunc(key, data, contentType, ttl)
 }
 
-func (m *MockStore) Get(key string, dest any) (*common.Meta, error) {
-	return m.GetFunc(key, dest)
+func (m *MockStore) CompareAndSet(key string, data []byte, contentType string, ttl time.Duration, version uint64) (*common.Meta, error) {
+	return m.CASFunc(key, data, contentType, ttl, version)
+}
+
+func (m *MockStore) SetNX(key string, data []byte, contentType string, ttl time.Duration) (*common.Meta, error) {
+	return m.SetNXFunc(key, data, contentType, ttl)
+}
+
+func (m *MockStore) SetXX(key string, data []byte, contentType string, ttl time.Duration) (*common.Meta, error) {
+	return m.SetXXFunc(key, data, contentType, ttl)
+}
+
+func (m *MockStore) GetBytes(key string) ([]byte, *common.Meta, error) {
+	return m.GetFunc(key)
 }
 
 func (m *MockStore) Delete(key string) error {
@@ -28,3 +81,191 @@ func (m *MockStore) Delete(key string) error {
 func (m *MockStore) Has(key string) bool {
 	return m.HasFunc(key)
 }
+
+func (m *MockStore) TTL(key string) (time.Duration, bool) {
+	return m.TTLFunc(key)
+}
+
+func (m *MockStore) Expire(key string, ttl time.Duration) error {
+	return m.ExpireFunc(key, ttl)
+}
+
+func (m *MockStore) Persist(key string) error {
+	return m.PersistFunc(key)
+}
+
+func (m *MockStore) Touch(key string) error {
+	return m.TouchFunc(key)
+}
+
+func (m *MockStore) Incr(key string, delta int64, ttl time.Duration) (int64, error) {
+	return m.IncrFunc(key, delta, ttl)
+}
+
+func (m *MockStore) Decr(key string, delta int64, ttl time.Duration) (int64, error) {
+	return m.IncrFunc(key, -delta, ttl)
+}
+
+func (m *MockStore) MGet(keys []string) []common.Result {
+	return m.MGetFunc(keys)
+}
+
+func (m *MockStore) MSet(entries []common.Entry) []common.Result {
+	return m.MSetFunc(entries)
+}
+
+func (m *MockStore) Tx(ops []common.TxOp) ([]common.Result, error) {
+	return m.TxFunc(ops)
+}
+
+func (m *MockStore) Scan(pattern, cursor string, count int) ([]string, string, error) {
+	return m.ScanFunc(pattern, cursor, count)
+}
+
+func (m *MockStore) DeletePrefix(prefix string) (int, error) {
+	return m.DeletePrefixFunc(prefix)
+}
+
+func (m *MockStore) DeleteMatching(pattern string) (int, error) {
+	return m.DeleteMatchingFunc(pattern)
+}
+
+func (m *MockStore) Flush() (int, error) {
+	return m.FlushFunc()
+}
+
+func (m *MockStore) HSet(key string, fields map[string]string) (int, error) {
+	return m.HSetFunc(key, fields)
+}
+
+func (m *MockStore) HGet(key, field string) (string, bool, error) {
+	return m.HGetFunc(key, field)
+}
+
+func (m *MockStore) HGetAll(key string) (map[string]string, error) {
+	return m.HGetAllFunc(key)
+}
+
+func (m *MockStore) HDel(key string, fields ...string) (int, error) {
+	return m.HDelFunc(key, fields...)
+}
+
+func (m *MockStore) HIncrBy(key, field string, delta int64) (int64, error) {
+	return m.HIncrByFunc(key, field, delta)
+}
+
+func (m *MockStore) LPush(key string, values ...string) (int, error) {
+	return m.PushFunc(key, true, values...)
+}
+
+func (m *MockStore) RPush(key string, values ...string) (int, error) {
+	return m.PushFunc(key, false, values...)
+}
+
+func (m *MockStore) LPop(key string) (string, bool, error) {
+	return m.PopFunc(key, true)
+}
+
+func (m *MockStore) RPop(key string) (string, bool, error) {
+	return m.PopFunc(key, false)
+}
+
+func (m *MockStore) BLPop(ctx context.Context, key string, timeout time.Duration) (string, bool, error) {
+	return m.BPopFunc(ctx, key, true, timeout)
+}
+
+func (m *MockStore) BRPop(ctx context.Context, key string, timeout time.Duration) (string, bool, error) {
+	return m.BPopFunc(ctx, key, false, timeout)
+}
+
+func (m *MockStore) LRange(key string, start, stop int) ([]string, error) {
+	return m.LRangeFunc(key, start, stop)
+}
+
+func (m *MockStore) LLen(key string) (int, error) {
+	return m.LLenFunc(key)
+}
+
+func (m *MockStore) ZAdd(key string, members ...common.ZMember) (int, error) {
+	return m.ZAddFunc(key, members...)
+}
+
+func (m *MockStore) ZRem(key string, members ...string) (int, error) {
+	return m.ZRemFunc(key, members...)
+}
+
+func (m *MockStore) ZScore(key, member string) (float64, bool, error) {
+	return m.ZScoreFunc(key, member)
+}
+
+func (m *MockStore) ZRank(key, member string) (int, bool, error) {
+	return m.ZRankFunc(key, member)
+}
+
+func (m *MockStore) ZRangeByScore(key string, minScore, maxScore float64, limit int) ([]common.ZMember, error) {
+	return m.ZRangeFunc(key, minScore, maxScore, limit)
+}
+
+func (m *MockStore) ZRemRangeByScore(key string, minScore, maxScore float64) (int, error) {
+	return m.ZRemRangeFunc(key, minScore, maxScore)
+}
+
+func (m *MockStore) SAdd(key string, members ...string) (int, error) {
+	return m.SAddFunc(key, members...)
+}
+
+func (m *MockStore) SRem(key string, members ...string) (int, error) {
+	return m.SRemFunc(key, members...)
+}
+
+func (m *MockStore) SIsMember(key, member string) (bool, error) {
+	return m.SIsMemberFunc(key, member)
+}
+
+func (m *MockStore) SMembers(key string) ([]string, error) {
+	return m.SMembersFunc(key)
+}
+
+func (m *MockStore) SCard(key string) (int, error) {
+	return m.SCardFunc(key)
+}
+
+func (m *MockStore) SInter(keys ...string) ([]string, error) {
+	return m.SetOpFunc("inter", keys...)
+}
+
+func (m *MockStore) SUnion(keys ...string) ([]string, error) {
+	return m.SetOpFunc("union", keys...)
+}
+
+func (m *MockStore) SDiff(keys ...string) ([]string, error) {
+	return m.SetOpFunc("diff", keys...)
+}
+
+func (m *MockStore) Watch(ctx context.Context, prefix string) <-chan common.Event {
+	return m.WatchFunc(ctx, prefix)
+}
+
+// MockNamespaces is a fixed set of namespaces.
+type MockNamespaces map[string]Store
+
+func (m MockNamespaces) Namespace(name string) (Store, bool) {
+	s, ok := m[name]
+	return s, ok
+}
+
+func (m MockNamespaces) Names() []string {
+	return slices.Sorted(maps.Keys(m))
+}
+
+func (m MockNamespaces) FlushAll() (int, error) {
+	total := 0
+	for _, name := range m.Names() {
+		n, err := m[name].Flush()
+		total += n
+		if err != nil {
+			return total, err
+		}
+	}
+	return total, nil
+}
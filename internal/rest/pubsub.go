@@ -0,0 +1,47 @@
+package rest
+
+import (
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SubscribeParams are the query parameters of a subscription: one or more
+// channel names or glob patterns.
+type SubscribeParams struct {
+	Patterns []string `form:"pattern" binding:"required,min=1"`
+}
+
+// PublishHandler publishes the request body to a channel and responds with
+// the number of subscribers that received it.
+func (s *Service) PublishHandler(c *gin.Context) {
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, newErr(errBadBody))
+		return
+	}
+	n := s.broker.Publish(c.Param("channel"), string(body))
+	c.JSON(http.StatusOK, gin.H{"receivers": n})
+}
+
+// SubscribeHandler streams the messages published to matching channels as
+// Server-Sent Events named "message" until the client disconnects.
+func (s *Service) SubscribeHandler(c *gin.Context) {
+	params := SubscribeParams{}
+	if err := c.ShouldBindQuery(&params); err != nil {
+		c.JSON(http.StatusBadRequest, newErr(errBadQuery))
+		return
+	}
+
+	messages := s.broker.Subscribe(c.Request.Context(), params.Patterns...)
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Status(http.StatusOK)
+	c.Writer.Flush()
+
+	for msg := range messages {
+		c.SSEvent("message", msg)
+		c.Writer.Flush()
+	}
+}
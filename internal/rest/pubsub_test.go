@@ -0,0 +1,58 @@
+package rest
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/johannessarpola/poor-cache-go/internal/pubsub"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPubSubHandlers(t *testing.T) {
+	broker := pubsub.New(0)
+	router := gin.Default()
+	SetupRouter(router.Group(""), New(&MockStore{}).WithPubSub(broker))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	sub := httptest.NewRecorder()
+	req, _ := http.NewRequestWithContext(ctx, "GET", "/subscribe?pattern=invalidate.*", nil)
+	done := make(chan struct{})
+	go func() {
+		router.ServeHTTP(sub, req)
+		close(done)
+	}()
+
+	// Publish until the subscription is in place.
+	var pub *httptest.ResponseRecorder
+	for range 100 {
+		pub = httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", "/publish/invalidate.users", strings.NewReader("user:1"))
+		router.ServeHTTP(pub, req)
+		if pub.Body.String() == `{"receivers":1}` {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	assert.Equal(t, http.StatusOK, pub.Code)
+	assert.JSONEq(t, `{"receivers": 1}`, pub.Body.String())
+
+	cancel()
+	<-done
+	assert.Equal(t, "text/event-stream", sub.Header().Get("Content-Type"))
+	assert.Equal(t, "event:message\ndata:{\"channel\":\"invalidate.users\",\"message\":\"user:1\"}\n\n", sub.Body.String())
+}
+
+func TestPubSubNeedsBroker(t *testing.T) {
+	router := gin.Default()
+	SetupRouter(router.Group(""), New(&MockStore{}))
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/subscribe?pattern=c", nil)
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
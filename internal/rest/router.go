@@ -2,10 +2,62 @@ package rest
 
 import "github.com/gin-gonic/gin"
 
+// defaultNamespace is the namespace of the routes outside /ns/:namespace.
+const defaultNamespace = "default"
+
 func SetupRouter(rg *gin.RouterGroup, svc *Service) {
+	setupRoutes(rg, svc)
+	setupRoutes(rg.Group("/ns/:namespace", svc.resolveNamespace), svc)
+	rg.POST("/admin/flushall", svc.FlushAllHandler)
+	if svc.broker != nil {
+		// Channels are shared by all namespaces.
+		rg.POST("/publish/:channel", svc.PublishHandler)
+		rg.GET("/subscribe", svc.SubscribeHandler)
+	}
+}
 
+func setupRoutes(rg *gin.RouterGroup, svc *Service) {
 	rg.POST("/set/:key", svc.SetHandler)
 	rg.GET("/get/:key", svc.GetHandler)
 	rg.DELETE("/delete/:key", svc.DeleteHandler)
 	rg.GET("/has/:key", svc.HasHandler)
+	rg.GET("/ttl/:key", svc.TTLHandler)
+	rg.POST("/expire/:key", svc.ExpireHandler)
+	rg.POST("/persist/:key", svc.PersistHandler)
+	rg.POST("/touch/:key", svc.TouchHandler)
+	rg.POST("/incr/:key", svc.IncrHandler)
+	rg.POST("/decr/:key", svc.DecrHandler)
+	rg.POST("/mget", svc.MGetHandler)
+	rg.POST("/mset", svc.MSetHandler)
+	rg.POST("/tx", svc.TxHandler)
+	rg.GET("/keys", svc.KeysHandler)
+	rg.DELETE("/keys", svc.DeleteKeysHandler)
+	rg.GET("/watch", svc.WatchHandler)
+	rg.POST("/admin/flush", svc.FlushHandler)
+	rg.GET("/hash/:key", svc.HGetAllHandler)
+	rg.POST("/hash/:key", svc.HSetAllHandler)
+	rg.GET("/hash/:key/:field", svc.HGetHandler)
+	rg.POST("/hash/:key/:field", svc.HSetHandler)
+	rg.DELETE("/hash/:key/:field", svc.HDelHandler)
+	rg.POST("/hash/:key/:field/incr", svc.HIncrByHandler)
+	rg.GET("/list/:key", svc.LRangeHandler)
+	rg.GET("/list/:key/len", svc.LLenHandler)
+	rg.POST("/list/:key/lpush", svc.LPushHandler)
+	rg.POST("/list/:key/rpush", svc.RPushHandler)
+	rg.POST("/list/:key/lpop", svc.LPopHandler)
+	rg.POST("/list/:key/rpop", svc.RPopHandler)
+	rg.GET("/zset/:key", svc.ZRangeHandler)
+	rg.POST("/zset/:key", svc.ZAddHandler)
+	rg.DELETE("/zset/:key", svc.ZRemRangeHandler)
+	rg.DELETE("/zset/:key/:member", svc.ZRemHandler)
+	rg.GET("/zset/:key/:member/score", svc.ZScoreHandler)
+	rg.GET("/zset/:key/:member/rank", svc.ZRankHandler)
+	rg.GET("/sets/:key", svc.SMembersHandler)
+	rg.POST("/sets/:key", svc.SAddHandler)
+	rg.GET("/sets/:key/len", svc.SCardHandler)
+	rg.GET("/sets/:key/members/:member", svc.SIsMemberHandler)
+	rg.DELETE("/sets/:key/members/:member", svc.SRemHandler)
+	rg.POST("/sinter", svc.SInterHandler)
+	rg.POST("/sunion", svc.SUnionHandler)
+	rg.POST("/sdiff", svc.SDiffHandler)
 }
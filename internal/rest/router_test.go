@@ -0,0 +1,132 @@
+package rest
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/johannessarpola/poor-cache-go/internal/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNamespacedRoutes(t *testing.T) {
+	getFrom := func(value string) func(key string) ([]byte, *common.Meta, error) {
+		return func(key string) ([]byte, *common.Meta, error) {
+			return []byte(value), &common.Meta{ContentType: common.ContentTypeJSON}, nil
+		}
+	}
+	def := &MockStore{GetFunc: getFrom(`"default"`)}
+	sessions := &MockStore{GetFunc: getFrom(`"sessions"`)}
+
+	tests := []struct {
+		name           string
+		path           string
+		namespaces     Namespaces
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:           "Default routes",
+			path:           "/api/v1/get/key",
+			expectedStatus: http.StatusOK,
+			expectedBody:   `"default"`,
+		},
+		{
+			name:           "Default namespace without lookup",
+			path:           "/api/v1/ns/default/get/key",
+			expectedStatus: http.StatusOK,
+			expectedBody:   `"default"`,
+		},
+		{
+			name:           "Named namespace",
+			path:           "/api/v1/ns/sessions/get/key",
+			namespaces:     MockNamespaces{"sessions": sessions},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `"sessions"`,
+		},
+		{
+			name:           "Unknown namespace",
+			path:           "/api/v1/ns/missing/get/key",
+			namespaces:     MockNamespaces{"sessions": sessions},
+			expectedStatus: http.StatusNotFound,
+			expectedBody:   errJson(errUnknownNamespace),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := New(def)
+			if tt.namespaces != nil {
+				service.WithNamespaces(tt.namespaces)
+			}
+			router := gin.Default()
+			SetupRouter(router.Group("/api/v1"), service)
+
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("GET", tt.path, nil)
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			assert.JSONEq(t, tt.expectedBody, w.Body.String())
+		})
+	}
+}
+
+func TestFlushHandlers(t *testing.T) {
+	flushed := map[string]int{}
+	flusher := func(name string, n int) *MockStore {
+		return &MockStore{FlushFunc: func() (int, error) {
+			flushed[name]++
+			return n, nil
+		}}
+	}
+	namespaces := MockNamespaces{
+		"default":  flusher("default", 1),
+		"sessions": flusher("sessions", 2),
+	}
+
+	tests := []struct {
+		name            string
+		path            string
+		expectedBody    string
+		expectedFlushes map[string]int
+	}{
+		{
+			name:            "Flush default",
+			path:            "/api/v1/admin/flush",
+			expectedBody:    `{"flushed": 1}`,
+			expectedFlushes: map[string]int{"default": 1},
+		},
+		{
+			name:            "Flush namespace",
+			path:            "/api/v1/ns/sessions/admin/flush",
+			expectedBody:    `{"flushed": 2}`,
+			expectedFlushes: map[string]int{"sessions": 1},
+		},
+		{
+			name:            "Flush all",
+			path:            "/api/v1/admin/flushall",
+			expectedBody:    `{"flushed": 3}`,
+			expectedFlushes: map[string]int{"default": 1, "sessions": 1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clear(flushed)
+			def, _ := namespaces.Namespace("default")
+			service := New(def).WithNamespaces(namespaces)
+			router := gin.Default()
+			SetupRouter(router.Group("/api/v1"), service)
+
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("POST", tt.path, nil)
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, http.StatusOK, w.Code)
+			assert.JSONEq(t, tt.expectedBody, w.Body.String())
+			assert.Equal(t, tt.expectedFlushes, flushed)
+		})
+	}
+}
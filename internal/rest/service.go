@@ -1,24 +1,126 @@
 package rest
 
 import (
+	"context"
+	"net/http"
 	"time"
 
+	"github.com/gin-gonic/gin"
 	"github.com/johannessarpola/poor-cache-go/internal/common"
+	"github.com/johannessarpola/poor-cache-go/internal/pubsub"
 )
 
 type Store interface {
-	Set(key string, value any, ttl time.Duration) error
-	Get(key string, dest any) (*common.Meta, error)
+	SetBytes(key string, data []byte, contentType string, ttl time.Duration) (*common.Meta, error)
+	CompareAndSet(key string, data []byte, contentType string, ttl time.Duration, version uint64) (*common.Meta, error)
+	SetNX(key string, data []byte, contentType string, ttl time.Duration) (*common.Meta, error)
+	SetXX(key string, data []byte, contentType string, ttl time.Duration) (*common.Meta, error)
+	GetBytes(key string) ([]byte, *common.Meta, error)
 	Delete(key string) error
 	Has(key string) bool
+	TTL(key string) (time.Duration, bool)
+	Expire(key string, ttl time.Duration) error
+	Persist(key string) error
+	Touch(key string) error
+	Incr(key string, delta int64, ttl time.Duration) (int64, error)
+	Decr(key string, delta int64, ttl time.Duration) (int64, error)
+	MGet(keys []string) []common.Result
+	MSet(entries []common.Entry) []common.Result
+	Tx(ops []common.TxOp) ([]common.Result, error)
+	Scan(pattern, cursor string, count int) ([]string, string, error)
+	DeletePrefix(prefix string) (int, error)
+	DeleteMatching(pattern string) (int, error)
+	Flush() (int, error)
+	HSet(key string, fields map[string]string) (int, error)
+	HGet(key, field string) (string, bool, error)
+	HGetAll(key string) (map[string]string, error)
+	HDel(key string, fields ...string) (int, error)
+	HIncrBy(key, field string, delta int64) (int64, error)
+	LPush(key string, values ...string) (int, error)
+	RPush(key string, values ...string) (int, error)
+	LPop(key string) (string, bool, error)
+	RPop(key string) (string, bool, error)
+	BLPop(ctx context.Context, key string, timeout time.Duration) (string, bool, error)
+	BRPop(ctx context.Context, key string, timeout time.Duration) (string, bool, error)
+	LRange(key string, start, stop int) ([]string, error)
+	LLen(key string) (int, error)
+	ZAdd(key string, members ...common.ZMember) (int, error)
+	ZRem(key string, members ...string) (int, error)
+	ZScore(key, member string) (float64, bool, error)
+	ZRank(key, member string) (int, bool, error)
+	ZRangeByScore(key string, minScore, maxScore float64, limit int) ([]common.ZMember, error)
+	ZRemRangeByScore(key string, minScore, maxScore float64) (int, error)
+	SAdd(key string, members ...string) (int, error)
+	SRem(key string, members ...string) (int, error)
+	SIsMember(key, member string) (bool, error)
+	SMembers(key string) ([]string, error)
+	SCard(key string) (int, error)
+	SInter(keys ...string) ([]string, error)
+	SUnion(keys ...string) ([]string, error)
+	SDiff(keys ...string) ([]string, error)
+	Watch(ctx context.Context, prefix string) <-chan common.Event
+}
+
+// Namespaces gives access to the stores of all namespaces.
+type Namespaces interface {
+	Namespace(name string) (Store, bool)
+	Names() []string
+	FlushAll() (int, error)
 }
 
 type Service struct {
-	store Store
+	store      Store
+	namespaces Namespaces
+	broker     *pubsub.Broker
 }
 
 func New(store Store) *Service {
 	return &Service{
-		store,
+		store: store,
+	}
+}
+
+// WithNamespaces makes the routes under /ns/:namespace resolve their store
+// through namespaces. Without it every namespace but the default is unknown.
+func (s *Service) WithNamespaces(namespaces Namespaces) *Service {
+	s.namespaces = namespaces
+	return s
+}
+
+// WithPubSub enables the publish and subscribe routes, delivering messages
+// through broker.
+func (s *Service) WithPubSub(broker *pubsub.Broker) *Service {
+	s.broker = broker
+	return s
+}
+
+// namespaceKey is the context key of the store resolved for a request.
+const namespaceKey = "poor-cache/store"
+
+// resolveNamespace is the middleware of namespaced routes. It looks up the
+// namespace of the request and aborts with 404 when it does not exist.
+func (s *Service) resolveNamespace(c *gin.Context) {
+	name := c.Param("namespace")
+	var st Store
+	var ok bool
+	switch {
+	case s.namespaces != nil:
+		st, ok = s.namespaces.Namespace(name)
+	case name == defaultNamespace:
+		st, ok = s.store, true
+	}
+	if !ok {
+		c.AbortWithStatusJSON(http.StatusNotFound, newErr(errUnknownNamespace))
+		return
+	}
+	c.Set(namespaceKey, st)
+	c.Next()
+}
+
+// storeFor returns the store a request operates on.
+func (s *Service) storeFor(c *gin.Context) Store {
+	if st, ok := c.Get(namespaceKey); ok {
+		return st.(Store)
 	}
+	return s.store
 }
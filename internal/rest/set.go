@@ -0,0 +1,106 @@
+package rest
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SetOpRequest names the sets combined by an intersection, union or
+// difference. A difference keeps the members of the first set that are in
+// none of the others.
+type SetOpRequest struct {
+	Keys []string `json:"keys" binding:"required,min=1"`
+}
+
+// SMembersHandler responds with the members of a set in order.
+func (s *Service) SMembersHandler(c *gin.Context) {
+	members, err := s.storeFor(c).SMembers(c.Param("key"))
+	if err != nil {
+		storeErr(c, err)
+		return
+	}
+	if members == nil {
+		c.JSON(http.StatusNotFound, newErr(errNotFound))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"members": members})
+}
+
+// SAddHandler adds the members of a JSON array of strings to a set.
+func (s *Service) SAddHandler(c *gin.Context) {
+	var members []string
+	if err := c.ShouldBindJSON(&members); err != nil || len(members) == 0 {
+		c.JSON(http.StatusBadRequest, newErr(errBadJson))
+		return
+	}
+
+	n, err := s.storeFor(c).SAdd(c.Param("key"), members...)
+	if err != nil {
+		storeErr(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"added": n})
+}
+
+// SIsMemberHandler responds with whether a member is in a set.
+func (s *Service) SIsMemberHandler(c *gin.Context) {
+	ok, err := s.storeFor(c).SIsMember(c.Param("key"), c.Param("member"))
+	if err != nil {
+		storeErr(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"member": ok})
+}
+
+// SRemHandler removes a single member from a set.
+func (s *Service) SRemHandler(c *gin.Context) {
+	n, err := s.storeFor(c).SRem(c.Param("key"), c.Param("member"))
+	if err != nil {
+		storeErr(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"removed": n})
+}
+
+func (s *Service) SCardHandler(c *gin.Context) {
+	n, err := s.storeFor(c).SCard(c.Param("key"))
+	if err != nil {
+		storeErr(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"length": n})
+}
+
+func (s *Service) SInterHandler(c *gin.Context) {
+	s.setOpHandler(c, s.storeFor(c).SInter)
+}
+
+func (s *Service) SUnionHandler(c *gin.Context) {
+	s.setOpHandler(c, s.storeFor(c).SUnion)
+}
+
+func (s *Service) SDiffHandler(c *gin.Context) {
+	s.setOpHandler(c, s.storeFor(c).SDiff)
+}
+
+// setOpHandler combines the sets named in the request and responds with the
+// resulting members. Missing keys count as empty sets.
+func (s *Service) setOpHandler(c *gin.Context, op func(keys ...string) ([]string, error)) {
+	var req SetOpRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, newErr(errBadJson))
+		return
+	}
+	if len(req.Keys) > maxBatchSize {
+		c.JSON(http.StatusBadRequest, newErr(errBatchSize))
+		return
+	}
+
+	members, err := op(req.Keys...)
+	if err != nil {
+		storeErr(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"members": members})
+}
@@ -0,0 +1,139 @@
+package rest
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/johannessarpola/poor-cache-go/internal/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSetHandlers(t *testing.T) {
+	tests := []struct {
+		name           string
+		method         string
+		path           string
+		body           string
+		expectedStatus int
+		expectedBody   string
+		store          *MockStore
+	}{
+		{
+			name:           "Add members",
+			method:         "POST",
+			path:           "/sets/tags",
+			body:           `["go", "cache"]`,
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"added": 2}`,
+			store: &MockStore{SAddFunc: func(key string, members ...string) (int, error) {
+				return len(members), nil
+			}},
+		},
+		{
+			name:           "Add to wrong type",
+			method:         "POST",
+			path:           "/sets/tags",
+			body:           `["go"]`,
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   errJson(errWrongType),
+			store: &MockStore{SAddFunc: func(key string, members ...string) (int, error) {
+				return 0, common.ErrWrongType
+			}},
+		},
+		{
+			name:           "Members",
+			method:         "GET",
+			path:           "/sets/tags",
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"members": ["cache", "go"]}`,
+			store: &MockStore{SMembersFunc: func(key string) ([]string, error) {
+				return []string{"cache", "go"}, nil
+			}},
+		},
+		{
+			name:           "Members of missing key",
+			method:         "GET",
+			path:           "/sets/tags",
+			expectedStatus: http.StatusNotFound,
+			expectedBody:   errJson(errNotFound),
+			store: &MockStore{SMembersFunc: func(key string) ([]string, error) {
+				return nil, nil
+			}},
+		},
+		{
+			name:           "Membership",
+			method:         "GET",
+			path:           "/sets/tags/members/go",
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"member": true}`,
+			store: &MockStore{SIsMemberFunc: func(key, member string) (bool, error) {
+				return member == "go", nil
+			}},
+		},
+		{
+			name:           "Remove member",
+			method:         "DELETE",
+			path:           "/sets/tags/members/go",
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"removed": 1}`,
+			store: &MockStore{SRemFunc: func(key string, members ...string) (int, error) {
+				return len(members), nil
+			}},
+		},
+		{
+			name:           "Length",
+			method:         "GET",
+			path:           "/sets/tags/len",
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"length": 2}`,
+			store: &MockStore{SCardFunc: func(key string) (int, error) {
+				return 2, nil
+			}},
+		},
+		{
+			name:           "Intersection",
+			method:         "POST",
+			path:           "/sinter",
+			body:           `{"keys": ["a", "b"]}`,
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"members": ["x"]}`,
+			store: &MockStore{SetOpFunc: func(op string, keys ...string) ([]string, error) {
+				if op != "inter" || !slices.Equal(keys, []string{"a", "b"}) {
+					return nil, assert.AnError
+				}
+				return []string{"x"}, nil
+			}},
+		},
+		{
+			name:           "Difference without keys",
+			method:         "POST",
+			path:           "/sdiff",
+			body:           `{"keys": []}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   errJson(errBadJson),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := tt.store
+			if store == nil {
+				store = &MockStore{}
+			}
+			service := New(store)
+			router := gin.Default()
+			SetupRouter(router.Group(""), service)
+
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest(tt.method, tt.path, bytes.NewBufferString(tt.body))
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			assert.JSONEq(t, tt.expectedBody, w.Body.String())
+		})
+	}
+}
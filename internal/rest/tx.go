@@ -0,0 +1,79 @@
+package rest
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/johannessarpola/poor-cache-go/internal/common"
+)
+
+const statusDeleted = "deleted"
+
+// TxOp is a single operation of a transaction. Writes carry their value like
+// MSET items, a cas also the version the key must hold.
+type TxOp struct {
+	Op string `json:"op" binding:"required,oneof=set delete cas"`
+	MSetItem
+	Version uint64 `json:"version,omitempty"`
+}
+
+type TxRequest struct {
+	Ops []TxOp `json:"ops" binding:"required,dive"`
+}
+
+// TxHandler applies a list of operations atomically. When an operation fails
+// nothing is applied and the response names the failing operation by index.
+func (s *Service) TxHandler(c *gin.Context) {
+	var req TxRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, newErr(errBadJson))
+		return
+	}
+	if len(req.Ops) > maxBatchSize {
+		c.JSON(http.StatusBadRequest, newErr(errBatchSize))
+		return
+	}
+
+	ops := make([]common.TxOp, 0, len(req.Ops))
+	for _, op := range req.Ops {
+		e := common.Entry{Key: op.Key}
+		if op.Op != common.OpDelete {
+			var err error
+			if e, err = op.entry(); err != nil {
+				c.JSON(http.StatusBadRequest, newErr(errBadJson))
+				return
+			}
+		}
+		ops = append(ops, common.TxOp{
+			Op:          op.Op,
+			Key:         e.Key,
+			Data:        e.Data,
+			ContentType: e.ContentType,
+			TTL:         e.TTL,
+			Version:     op.Version,
+		})
+	}
+
+	results, err := s.storeFor(c).Tx(ops)
+	if err != nil {
+		status, e := storeErrStatus(err)
+		body := newErr(e)
+		var txErr *common.TxError
+		if errors.As(err, &txErr) {
+			body["op"] = txErr.Index
+		}
+		c.JSON(status, body)
+		return
+	}
+
+	res := make([]BatchResult, 0, len(results))
+	for i, r := range results {
+		status := statusStored
+		if ops[i].Op == common.OpDelete {
+			status = statusDeleted
+		}
+		res = append(res, BatchResult{Key: r.Key, Status: status, Meta: r.Meta})
+	}
+	c.JSON(http.StatusOK, gin.H{"results": res})
+}
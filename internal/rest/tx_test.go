@@ -0,0 +1,82 @@
+package rest
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/johannessarpola/poor-cache-go/internal/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTxHandler(t *testing.T) {
+	tests := []struct {
+		name           string
+		body           string
+		expectedStatus int
+		expectedBody   string
+		txFunc         func(ops []common.TxOp) ([]common.Result, error)
+	}{
+		{
+			name:           "Successful transaction",
+			body:           `{"ops": [{"op": "cas", "key": "user", "value": {"n": 1}, "version": 3}, {"op": "delete", "key": "index"}]}`,
+			expectedStatus: http.StatusOK,
+			expectedBody: `{"results": [
+				{"key": "user", "status": "stored", "meta": ` + asJsonStr(common.Meta{Version: 4}) + `},
+				{"key": "index", "status": "deleted"}
+			]}`,
+			txFunc: func(ops []common.TxOp) ([]common.Result, error) {
+				if ops[0].Op != common.OpCAS || ops[0].Version != 3 || string(ops[0].Data) != `{"n": 1}` || ops[1].Data != nil {
+					return nil, assert.AnError
+				}
+				return []common.Result{
+					{Key: "user", Meta: &common.Meta{Version: 4}},
+					{Key: "index"},
+				}, nil
+			},
+		},
+		{
+			name:           "Failed precondition",
+			body:           `{"ops": [{"op": "set", "key": "a", "value": 1}, {"op": "cas", "key": "b", "value": 2, "version": 1}]}`,
+			expectedStatus: http.StatusPreconditionFailed,
+			expectedBody:   `{"error": "precondition failed", "op": 1}`,
+			txFunc: func(ops []common.TxOp) ([]common.Result, error) {
+				return nil, &common.TxError{Index: 1, Err: common.ErrVersionMismatch}
+			},
+		},
+		{
+			name:           "Unknown operation",
+			body:           `{"ops": [{"op": "incr", "key": "a"}]}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   errJson(errBadJson),
+		},
+		{
+			name:           "Set without value",
+			body:           `{"ops": [{"op": "set", "key": "a"}]}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   errJson(errBadJson),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockStore := &MockStore{
+				TxFunc: tt.txFunc,
+			}
+
+			service := New(mockStore)
+			router := gin.Default()
+			router.POST("/tx", service.TxHandler)
+
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("POST", "/tx", bytes.NewBufferString(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			assert.JSONEq(t, tt.expectedBody, w.Body.String())
+		})
+	}
+}
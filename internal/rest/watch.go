@@ -0,0 +1,36 @@
+package rest
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// WatchParams are the query parameters of a keyspace watch. Without a prefix
+// every key is watched.
+type WatchParams struct {
+	Prefix string `form:"prefix"`
+}
+
+// WatchHandler streams keyspace events as Server-Sent Events until the client
+// disconnects. Each event is named after its operation and carries the event
+// as JSON. An overflow event means events were dropped because the client
+// fell behind, and cached copies should be revalidated.
+func (s *Service) WatchHandler(c *gin.Context) {
+	params := WatchParams{}
+	if err := c.ShouldBindQuery(&params); err != nil {
+		c.JSON(http.StatusBadRequest, newErr(errBadQuery))
+		return
+	}
+
+	events := s.storeFor(c).Watch(c.Request.Context(), params.Prefix)
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Status(http.StatusOK)
+	c.Writer.Flush()
+
+	for e := range events {
+		c.SSEvent(e.Op, e)
+		c.Writer.Flush()
+	}
+}
@@ -0,0 +1,36 @@
+package rest
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/johannessarpola/poor-cache-go/internal/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWatchHandler(t *testing.T) {
+	var watched string
+	store := &MockStore{WatchFunc: func(ctx context.Context, prefix string) <-chan common.Event {
+		watched = prefix
+		events := make(chan common.Event, 2)
+		events <- common.Event{Key: "user:1", Op: common.EventSet, Version: 7}
+		events <- common.Event{Op: common.EventOverflow}
+		close(events)
+		return events
+	}}
+	router := gin.Default()
+	SetupRouter(router.Group(""), New(store))
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/watch?prefix=user:", nil)
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "user:", watched)
+	assert.Equal(t, "text/event-stream", w.Header().Get("Content-Type"))
+	assert.Equal(t, "event:set\ndata:{\"key\":\"user:1\",\"op\":\"set\",\"version\":7}\n\n"+
+		"event:overflow\ndata:{\"op\":\"overflow\"}\n\n", w.Body.String())
+}
@@ -0,0 +1,142 @@
+package rest
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/johannessarpola/poor-cache-go/internal/common"
+)
+
+// ScoreRangeParams select the members of a sorted set by score, both bounds
+// inclusive. Bounds are numbers or "inf" and "-inf"; ranges are open by
+// default. A positive limit caps the number of members returned.
+type ScoreRangeParams struct {
+	Min   string `form:"min,default=-inf"`
+	Max   string `form:"max,default=inf"`
+	Limit int    `form:"limit" binding:"min=0"`
+}
+
+// ScoreRemoveParams select the members of a sorted set to remove by score.
+// Both bounds are required so that a bare DELETE cannot empty the set.
+type ScoreRemoveParams struct {
+	Min string `form:"min" binding:"required"`
+	Max string `form:"max" binding:"required"`
+}
+
+// parseScores parses the bounds of a score range.
+func parseScores(minStr, maxStr string) (float64, float64, bool) {
+	minScore, err := strconv.ParseFloat(minStr, 64)
+	if err != nil || math.IsNaN(minScore) {
+		return 0, 0, false
+	}
+	maxScore, err := strconv.ParseFloat(maxStr, 64)
+	if err != nil || math.IsNaN(maxScore) {
+		return 0, 0, false
+	}
+	return minScore, maxScore, true
+}
+
+// ZAddHandler adds the members of a JSON array of {"member", "score"} objects
+// to a sorted set, updating the scores of existing members.
+func (s *Service) ZAddHandler(c *gin.Context) {
+	var members []common.ZMember
+	if err := c.ShouldBindJSON(&members); err != nil || len(members) == 0 {
+		c.JSON(http.StatusBadRequest, newErr(errBadJson))
+		return
+	}
+
+	n, err := s.storeFor(c).ZAdd(c.Param("key"), members...)
+	if err != nil {
+		storeErr(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"added": n})
+}
+
+// ZRangeHandler responds with the members of a sorted set in a score range,
+// lowest score first.
+func (s *Service) ZRangeHandler(c *gin.Context) {
+	params := ScoreRangeParams{}
+	if err := c.ShouldBindQuery(&params); err != nil {
+		c.JSON(http.StatusBadRequest, newErr(errBadQuery))
+		return
+	}
+	minScore, maxScore, ok := parseScores(params.Min, params.Max)
+	if !ok {
+		c.JSON(http.StatusBadRequest, newErr(errBadQuery))
+		return
+	}
+
+	members, err := s.storeFor(c).ZRangeByScore(c.Param("key"), minScore, maxScore, params.Limit)
+	if err != nil {
+		storeErr(c, err)
+		return
+	}
+	if members == nil {
+		c.JSON(http.StatusNotFound, newErr(errNotFound))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"members": members})
+}
+
+// ZRemRangeHandler removes the members of a sorted set in a score range.
+func (s *Service) ZRemRangeHandler(c *gin.Context) {
+	params := ScoreRemoveParams{}
+	if err := c.ShouldBindQuery(&params); err != nil {
+		c.JSON(http.StatusBadRequest, newErr(errBadQuery))
+		return
+	}
+	minScore, maxScore, ok := parseScores(params.Min, params.Max)
+	if !ok {
+		c.JSON(http.StatusBadRequest, newErr(errBadQuery))
+		return
+	}
+
+	n, err := s.storeFor(c).ZRemRangeByScore(c.Param("key"), minScore, maxScore)
+	if err != nil {
+		storeErr(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"removed": n})
+}
+
+// ZRemHandler removes a single member from a sorted set.
+func (s *Service) ZRemHandler(c *gin.Context) {
+	n, err := s.storeFor(c).ZRem(c.Param("key"), c.Param("member"))
+	if err != nil {
+		storeErr(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"removed": n})
+}
+
+// ZScoreHandler responds with the score of a member of a sorted set.
+func (s *Service) ZScoreHandler(c *gin.Context) {
+	score, ok, err := s.storeFor(c).ZScore(c.Param("key"), c.Param("member"))
+	if err != nil {
+		storeErr(c, err)
+		return
+	}
+	if !ok {
+		c.JSON(http.StatusNotFound, newErr(errNotFound))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"score": score})
+}
+
+// ZRankHandler responds with the zero-based rank of a member of a sorted set,
+// lowest score first.
+func (s *Service) ZRankHandler(c *gin.Context) {
+	rank, ok, err := s.storeFor(c).ZRank(c.Param("key"), c.Param("member"))
+	if err != nil {
+		storeErr(c, err)
+		return
+	}
+	if !ok {
+		c.JSON(http.StatusNotFound, newErr(errNotFound))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"rank": rank})
+}
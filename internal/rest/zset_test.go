@@ -0,0 +1,170 @@
+package rest
+
+import (
+	"bytes"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/johannessarpola/poor-cache-go/internal/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSortedSetHandlers(t *testing.T) {
+	tests := []struct {
+		name           string
+		method         string
+		path           string
+		body           string
+		expectedStatus int
+		expectedBody   string
+		store          *MockStore
+	}{
+		{
+			name:           "Add members",
+			method:         "POST",
+			path:           "/zset/board",
+			body:           `[{"member": "alice", "score": 10}, {"member": "bob", "score": 2.5}]`,
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"added": 2}`,
+			store: &MockStore{ZAddFunc: func(key string, members ...common.ZMember) (int, error) {
+				if members[1] != (common.ZMember{Member: "bob", Score: 2.5}) {
+					return 0, assert.AnError
+				}
+				return len(members), nil
+			}},
+		},
+		{
+			name:           "Add nothing",
+			method:         "POST",
+			path:           "/zset/board",
+			body:           `[]`,
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   errJson(errBadJson),
+		},
+		{
+			name:           "Range defaults to everything",
+			method:         "GET",
+			path:           "/zset/board",
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"members": [{"member": "bob", "score": 2.5}]}`,
+			store: &MockStore{ZRangeFunc: func(key string, minScore, maxScore float64, limit int) ([]common.ZMember, error) {
+				if !math.IsInf(minScore, -1) || !math.IsInf(maxScore, 1) || limit != 0 {
+					return nil, assert.AnError
+				}
+				return []common.ZMember{{Member: "bob", Score: 2.5}}, nil
+			}},
+		},
+		{
+			name:           "Range with bounds and limit",
+			method:         "GET",
+			path:           "/zset/board?min=5&max=inf&limit=3",
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"members": []}`,
+			store: &MockStore{ZRangeFunc: func(key string, minScore, maxScore float64, limit int) ([]common.ZMember, error) {
+				if minScore != 5 || !math.IsInf(maxScore, 1) || limit != 3 {
+					return nil, assert.AnError
+				}
+				return []common.ZMember{}, nil
+			}},
+		},
+		{
+			name:           "Range with bad bound",
+			method:         "GET",
+			path:           "/zset/board?min=low",
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   errJson(errBadQuery),
+		},
+		{
+			name:           "Range of missing key",
+			method:         "GET",
+			path:           "/zset/board",
+			expectedStatus: http.StatusNotFound,
+			expectedBody:   errJson(errNotFound),
+			store: &MockStore{ZRangeFunc: func(key string, minScore, maxScore float64, limit int) ([]common.ZMember, error) {
+				return nil, nil
+			}},
+		},
+		{
+			name:           "Remove range needs bounds",
+			method:         "DELETE",
+			path:           "/zset/board?min=0",
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   errJson(errBadQuery),
+		},
+		{
+			name:           "Remove range",
+			method:         "DELETE",
+			path:           "/zset/board?min=-inf&max=10",
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"removed": 4}`,
+			store: &MockStore{ZRemRangeFunc: func(key string, minScore, maxScore float64) (int, error) {
+				if !math.IsInf(minScore, -1) || maxScore != 10 {
+					return 0, assert.AnError
+				}
+				return 4, nil
+			}},
+		},
+		{
+			name:           "Remove member",
+			method:         "DELETE",
+			path:           "/zset/board/alice",
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"removed": 1}`,
+			store: &MockStore{ZRemFunc: func(key string, members ...string) (int, error) {
+				return len(members), nil
+			}},
+		},
+		{
+			name:           "Score",
+			method:         "GET",
+			path:           "/zset/board/alice/score",
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"score": 10}`,
+			store: &MockStore{ZScoreFunc: func(key, member string) (float64, bool, error) {
+				return 10, true, nil
+			}},
+		},
+		{
+			name:           "Rank of missing member",
+			method:         "GET",
+			path:           "/zset/board/nobody/rank",
+			expectedStatus: http.StatusNotFound,
+			expectedBody:   errJson(errNotFound),
+			store: &MockStore{ZRankFunc: func(key, member string) (int, bool, error) {
+				return 0, false, nil
+			}},
+		},
+		{
+			name:           "Rank of wrong type",
+			method:         "GET",
+			path:           "/zset/board/alice/rank",
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   errJson(errWrongType),
+			store: &MockStore{ZRankFunc: func(key, member string) (int, bool, error) {
+				return 0, false, common.ErrWrongType
+			}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := tt.store
+			if store == nil {
+				store = &MockStore{}
+			}
+			service := New(store)
+			router := gin.Default()
+			SetupRouter(router.Group(""), service)
+
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest(tt.method, tt.path, bytes.NewBufferString(tt.body))
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			assert.JSONEq(t, tt.expectedBody, w.Body.String())
+		})
+	}
+}
@@ -0,0 +1,441 @@
+package store
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/johannessarpola/poor-cache-go/internal/common"
+	"github.com/johannessarpola/poor-cache-go/internal/logger"
+)
+
+// FsyncPolicy controls how often the append-only log is flushed to disk.
+type FsyncPolicy int
+
+const (
+	// FsyncAlways syncs after every record, before the write is acknowledged.
+	FsyncAlways FsyncPolicy = iota
+	// FsyncEverySecond syncs from a background ticker once a second.
+	FsyncEverySecond
+	// FsyncNever leaves flushing to the operating system.
+	FsyncNever
+)
+
+func ParseFsyncPolicy(s string) (FsyncPolicy, error) {
+	switch s {
+	case "always":
+		return FsyncAlways, nil
+	case "everysec", "":
+		return FsyncEverySecond, nil
+	case "never":
+		return FsyncNever, nil
+	}
+	return 0, fmt.Errorf("unknown fsync policy %q", s)
+}
+
+const (
+	opSet    = "set"
+	opDelete = "del"
+	opTx     = "tx"
+	opFlush  = "flush"
+)
+
+// logRecord is a single log entry. Sets carry the whole item, including its
+// absolute expiration, so replay restores exactly what was acknowledged. A
+// transaction is one record holding the records of all its operations, so it
+// is replayed either completely or not at all.
+type logRecord struct {
+	Op   string       `json:"op"`
+	Key  string       `json:"key,omitempty"`
+	Item *common.Item `json:"item,omitempty"`
+	Ops  []logRecord  `json:"ops,omitempty"`
+}
+
+// Records are framed as length (uint32) | crc32 (uint32) | json payload, so a
+// record torn by a crash is detected on replay.
+const frameHeaderLen = 8
+
+func encodeRecord(rec logRecord) ([]byte, error) {
+	payload, err := json.Marshal(rec)
+	if err != nil {
+		return nil, err
+	}
+	frame := make([]byte, frameHeaderLen+len(payload))
+	binary.BigEndian.PutUint32(frame[0:4], uint32(len(payload)))
+	binary.BigEndian.PutUint32(frame[4:8], crc32.ChecksumIEEE(payload))
+	copy(frame[frameHeaderLen:], payload)
+	return frame, nil
+}
+
+// WithAppendLog makes every Set and Delete go through an append-only log at
+// path before it is acknowledged. The log is replayed when the store is created
+// and rewritten in the background once it grows past rewriteSize bytes and to
+// twice its size after the previous rewrite (zero disables rewrites). When the
+// log is enabled it takes precedence over snapshots on startup.
+func WithAppendLog(path string, fsync FsyncPolicy, rewriteSize int64) Option {
+	return func(s *Store) {
+		s.logPath = path
+		s.logFsync = fsync
+		s.logRewriteSize = rewriteSize
+	}
+}
+
+type appendLog struct {
+	mu          sync.Mutex
+	path        string
+	f           *os.File
+	size        int64
+	baseSize    int64 // size after the last replay or rewrite
+	fsync       FsyncPolicy
+	rewriteSize int64
+	rewriting   bool
+	rewriteBuf  [][]byte
+	closed      bool
+	quit        chan struct{}
+	wg          sync.WaitGroup
+}
+
+func openAppendLog(path string, fsync FsyncPolicy, rewriteSize int64) (*appendLog, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	l := &appendLog{
+		path:        path,
+		f:           f,
+		fsync:       fsync,
+		rewriteSize: rewriteSize,
+		quit:        make(chan struct{}),
+	}
+	if fsync == FsyncEverySecond {
+		l.wg.Add(1)
+		go l.syncPeriodically()
+	}
+	return l, nil
+}
+
+// replay reads every intact record from the start of the log and hands it to
+// apply. A torn record at the tail is cut off so new records follow the last
+// good one.
+func (l *appendLog) replay(apply func(logRecord)) (int, error) {
+	if _, err := l.f.Seek(0, io.SeekStart); err != nil {
+		return 0, err
+	}
+
+	r := bufio.NewReader(l.f)
+	var offset int64
+	n := 0
+	for {
+		rec, size, err := readRecord(r)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			logger.Warnf("Discarding torn append log tail at offset %d: %s", offset, err)
+			if err := l.f.Truncate(offset); err != nil {
+				return n, err
+			}
+			break
+		}
+		apply(rec)
+		offset += size
+		n++
+	}
+
+	l.size = offset
+	l.baseSize = offset
+	_, err := l.f.Seek(offset, io.SeekStart)
+	return n, err
+}
+
+func readRecord(r *bufio.Reader) (logRecord, int64, error) {
+	var rec logRecord
+	header := make([]byte, frameHeaderLen)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return rec, 0, err // io.EOF on a clean end of the log
+	}
+
+	payload := make([]byte, binary.BigEndian.Uint32(header[0:4]))
+	if _, err := io.ReadFull(r, payload); err != nil {
+		return rec, 0, io.ErrUnexpectedEOF
+	}
+	if crc32.ChecksumIEEE(payload) != binary.BigEndian.Uint32(header[4:8]) {
+		return rec, 0, errors.New("checksum mismatch")
+	}
+	if err := json.Unmarshal(payload, &rec); err != nil {
+		return rec, 0, err
+	}
+	return rec, int64(frameHeaderLen + len(payload)), nil
+}
+
+// append writes a record and, depending on the fsync policy, syncs it before
+// returning. It reports whether the log has grown enough to be rewritten.
+func (l *appendLog) append(rec logRecord) (bool, error) {
+	frame, err := encodeRecord(rec)
+	if err != nil {
+		return false, err
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.closed {
+		return false, os.ErrClosed
+	}
+
+	if _, err := l.f.Write(frame); err != nil {
+		return false, err
+	}
+	l.size += int64(len(frame))
+	if l.fsync == FsyncAlways {
+		if err := l.f.Sync(); err != nil {
+			return false, err
+		}
+	}
+
+	if l.rewriting {
+		l.rewriteBuf = append(l.rewriteBuf, frame)
+		return false, nil
+	}
+	return l.needsRewrite(), nil
+}
+
+// needsRewrite reports whether the log has grown past rewriteSize and to at
+// least twice its size after the last rewrite. Measuring growth rather than
+// the absolute size keeps a store whose live data alone exceeds rewriteSize
+// from rewriting on every write.
+func (l *appendLog) needsRewrite() bool {
+	return l.rewriteSize > 0 && l.size > max(l.rewriteSize, 2*l.baseSize)
+}
+
+// rewrite replaces the log with one set record per live item. Records appended
+// while the items are being written out are buffered and added to the new log
+// before it is swapped in, so nothing acknowledged in the meantime is lost.
+func (l *appendLog) rewrite(entries func() []snapshotEntry) error {
+	l.mu.Lock()
+	if l.rewriting || l.closed {
+		l.mu.Unlock()
+		return nil
+	}
+	l.rewriting = true
+	l.rewriteBuf = nil
+	l.wg.Add(1)
+	l.mu.Unlock()
+	defer l.wg.Done()
+
+	tmp, err := os.CreateTemp(filepath.Dir(l.path), filepath.Base(l.path)+".rewrite-*")
+	if err != nil {
+		l.abortRewrite()
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	w := bufio.NewWriter(tmp)
+	var size int64
+	for _, e := range entries() {
+		item := e.Item
+		frame, err := encodeRecord(logRecord{Op: opSet, Key: e.Key, Item: &item})
+		if err != nil {
+			tmp.Close()
+			l.abortRewrite()
+			return err
+		}
+		w.Write(frame)
+		size += int64(len(frame))
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.rewriting = false
+	if l.closed {
+		tmp.Close()
+		return os.ErrClosed
+	}
+
+	for _, frame := range l.rewriteBuf {
+		w.Write(frame)
+		size += int64(len(frame))
+	}
+	l.rewriteBuf = nil
+
+	if err := w.Flush(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := os.Rename(tmp.Name(), l.path); err != nil {
+		tmp.Close()
+		return err
+	}
+
+	l.f.Close()
+	l.f = tmp
+	l.size = size
+	l.baseSize = size
+	return nil
+}
+
+func (l *appendLog) abortRewrite() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.rewriting = false
+	l.rewriteBuf = nil
+}
+
+func (l *appendLog) syncPeriodically() {
+	defer l.wg.Done()
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-l.quit:
+			return
+		case <-ticker.C:
+			l.mu.Lock()
+			if err := l.f.Sync(); err != nil {
+				logger.Errorf("Could not sync append log %s: %s", l.path, err)
+			}
+			l.mu.Unlock()
+		}
+	}
+}
+
+func (l *appendLog) close() error {
+	l.mu.Lock()
+	if l.closed {
+		l.mu.Unlock()
+		return nil
+	}
+	l.closed = true
+	close(l.quit)
+	l.mu.Unlock()
+
+	l.wg.Wait()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if err := l.f.Sync(); err != nil {
+		l.f.Close()
+		return err
+	}
+	return l.f.Close()
+}
+
+// logSet records a write. The caller must hold the key's shard lock so that
+// records for a key are logged in the order they are applied.
+func (s *Store) logSet(key string, item common.Item) error {
+	if s.log == nil {
+		return nil
+	}
+	return s.logRecord(logRecord{Op: opSet, Key: key, Item: &item})
+}
+
+// logDelete records a removal. The caller must hold the key's shard lock.
+func (s *Store) logDelete(keys ...string) error {
+	if s.log == nil {
+		return nil
+	}
+	for _, key := range keys {
+		if err := s.logRecord(logRecord{Op: opDelete, Key: key}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// logTx records the operations of a transaction as a single record. The caller
+// must hold the locks of all shards involved.
+func (s *Store) logTx(ops []logRecord) error {
+	if s.log == nil || len(ops) == 0 {
+		return nil
+	}
+	return s.logRecord(logRecord{Op: opTx, Ops: ops})
+}
+
+func (s *Store) logRecord(rec logRecord) error {
+	full, err := s.log.append(rec)
+	if err != nil {
+		logger.Errorf("Could not append to log %s: %s", s.logPath, err)
+		return err
+	}
+	if full {
+		go s.rewriteLog()
+	}
+	return nil
+}
+
+func (s *Store) rewriteLog() {
+	if err := s.log.rewrite(s.liveEntries); err != nil {
+		logger.Errorf("Could not rewrite append log %s: %s", s.logPath, err)
+	}
+}
+
+// RewriteLog compacts the append-only log down to the current contents of the
+// store. It is a no-op when the log is disabled or a rewrite is in progress.
+func (s *Store) RewriteLog() error {
+	if s.log == nil {
+		return nil
+	}
+	return s.log.rewrite(s.liveEntries)
+}
+
+func (s *Store) restoreLog() {
+	l, err := openAppendLog(s.logPath, s.logFsync, s.logRewriteSize)
+	if err != nil {
+		logger.Errorf("Could not open append log %s: %s", s.logPath, err)
+		return
+	}
+	// The log is only attached once replayed, so that evictions during the
+	// replay are not appended to the file being read.
+	now := time.Now()
+	n, err := l.replay(func(rec logRecord) {
+		switch rec.Op {
+		case opTx:
+			for _, op := range rec.Ops {
+				s.applyRecord(op, now)
+			}
+		case opFlush:
+			for _, sh := range s.shards {
+				sh.mu.Lock()
+				sh.clear()
+				sh.mu.Unlock()
+			}
+		default:
+			s.applyRecord(rec, now)
+		}
+		s.evict()
+	})
+	s.log = l
+	if err != nil {
+		logger.Errorf("Could not replay append log %s: %s", s.logPath, err)
+		return
+	}
+	logger.Infof("Replayed %d records from append log %s", n, s.logPath)
+}
+
+func (s *Store) applyRecord(rec logRecord, now time.Time) {
+	sh := s.shardFor(rec.Key)
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+	switch rec.Op {
+	case opSet:
+		if rec.Item == nil || rec.Item.Expired(now) {
+			sh.remove(rec.Key)
+			return
+		}
+		s.observeVersion(rec.Item.Value.Meta.Version)
+		sh.put(rec.Key, *rec.Item)
+	case opDelete:
+		sh.remove(rec.Key)
+	}
+}
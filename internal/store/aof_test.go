@@ -0,0 +1,114 @@
+package store
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestAppendLogReplay(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache.aof")
+
+	s := New(WithAppendLog(path, FsyncAlways, 0))
+	s.Set("a", "first", time.Minute)
+	s.Set("a", "second", time.Minute)
+	s.Set("b", "deleted", time.Minute)
+	s.Delete("b")
+	s.Set("c", "expiring", 20*time.Millisecond)
+	s.Close()
+
+	time.Sleep(30 * time.Millisecond)
+
+	restored := New(WithAppendLog(path, FsyncAlways, 0))
+	defer restored.Close()
+
+	var v string
+	if meta, _ := restored.Get("a", &v); meta == nil || v != "second" {
+		t.Fatalf("expected a to be replayed as second, got %q", v)
+	}
+	if restored.Has("b") {
+		t.Fatalf("expected b to stay deleted")
+	}
+	if restored.Has("c") {
+		t.Fatalf("expected c to keep its original expiration")
+	}
+}
+
+func TestAppendLogTornTail(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache.aof")
+
+	s := New(WithAppendLog(path, FsyncNever, 0))
+	s.Set("a", "kept", time.Minute)
+	s.Close()
+
+	f, _ := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0o644)
+	f.Write([]byte{0, 0, 1, 0, 1, 2})
+	f.Close()
+
+	restored := New(WithAppendLog(path, FsyncNever, 0))
+	restored.Set("b", "after", time.Minute)
+	restored.Close()
+
+	again := New(WithAppendLog(path, FsyncNever, 0))
+	defer again.Close()
+	if !again.Has("a") || !again.Has("b") {
+		t.Fatalf("expected records around the torn tail to survive")
+	}
+}
+
+func TestAppendLogRewrite(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache.aof")
+
+	s := New(WithAppendLog(path, FsyncNever, 0))
+	for i := 0; i < 100; i++ {
+		s.Set("a", i, time.Minute)
+	}
+	s.Set("b", "gone", time.Minute)
+	s.Delete("b")
+
+	before, _ := os.Stat(path)
+	if err := s.RewriteLog(); err != nil {
+		t.Fatalf("RewriteLog failed: %v", err)
+	}
+	s.Set("c", "after rewrite", time.Minute)
+	s.Close()
+
+	after, _ := os.Stat(path)
+	if after.Size() >= before.Size() {
+		t.Fatalf("expected rewrite to shrink the log, %d >= %d", after.Size(), before.Size())
+	}
+
+	restored := New(WithAppendLog(path, FsyncNever, 0))
+	defer restored.Close()
+
+	var v int
+	if meta, _ := restored.Get("a", &v); meta == nil || v != 99 {
+		t.Fatalf("expected a to be 99, got %d", v)
+	}
+	if restored.Has("b") || !restored.Has("c") {
+		t.Fatalf("unexpected keys after rewrite")
+	}
+}
+
+func TestAppendLogRewriteOnGrowth(t *testing.T) {
+	l, err := openAppendLog(filepath.Join(t.TempDir(), "cache.aof"), FsyncNever, 100)
+	if err != nil {
+		t.Fatalf("openAppendLog failed: %v", err)
+	}
+	defer l.close()
+
+	l.size, l.baseSize = 150, 150
+	if l.needsRewrite() {
+		t.Fatalf("expected no rewrite right after compacting to %d bytes", l.baseSize)
+	}
+	l.size = 301
+	if !l.needsRewrite() {
+		t.Fatalf("expected a rewrite once the log doubled")
+	}
+
+	l.size, l.baseSize = 101, 10
+	if !l.needsRewrite() {
+		t.Fatalf("expected a rewrite past rewriteSize")
+	}
+}
@@ -0,0 +1,26 @@
+package store
+
+import "github.com/johannessarpola/poor-cache-go/internal/common"
+
+// MGet reads several keys at once. Results are in the order of keys; missing
+// keys have a nil Meta.
+func (s *Store) MGet(keys []string) []common.Result {
+	results := make([]common.Result, len(keys))
+	for i, key := range keys {
+		data, meta, err := s.GetBytes(key)
+		results[i] = common.Result{Key: key, Data: data, Meta: meta, Err: err}
+	}
+	return results
+}
+
+// MSet writes several entries at once. Every entry is written independently,
+// so a failing entry does not stop the others. Results are in the order of
+// entries and carry the metadata of the written items.
+func (s *Store) MSet(entries []common.Entry) []common.Result {
+	results := make([]common.Result, len(entries))
+	for i, e := range entries {
+		meta, err := s.SetBytes(e.Key, e.Data, e.ContentType, e.TTL)
+		results[i] = common.Result{Key: e.Key, Meta: meta, Err: err}
+	}
+	return results
+}
@@ -0,0 +1,37 @@
+package store
+
+import (
+	"testing"
+	"time"
+
+	"github.com/johannessarpola/poor-cache-go/internal/common"
+)
+
+func TestMSetAndMGet(t *testing.T) {
+	s := New(WithMaxBytes(64), WithCodec(JSON))
+	defer s.Close()
+
+	results := s.MSet([]common.Entry{
+		{Key: "a", Data: []byte(`{"n": 1}`), ContentType: "application/json", TTL: time.Minute},
+		{Key: "b", Data: []byte("plain"), ContentType: "text/plain"},
+		{Key: "c", Data: make([]byte, 128)},
+	})
+
+	if results[0].Err != nil || results[0].Meta == nil || results[1].Err != nil {
+		t.Fatalf("expected a and b to be stored: %v, %v", results[0].Err, results[1].Err)
+	}
+	if results[2].Err == nil {
+		t.Fatalf("expected c to fail without affecting the others")
+	}
+
+	got := s.MGet([]string{"b", "missing", "a"})
+	if got[0].Key != "b" || string(got[0].Data) != "plain" || got[0].Meta.ContentType != "text/plain" {
+		t.Fatalf("unexpected result for b: %#v", got[0])
+	}
+	if got[1].Meta != nil || got[1].Err != nil {
+		t.Fatalf("expected missing to be reported as not found: %#v", got[1])
+	}
+	if string(got[2].Data) != `{"n": 1}` {
+		t.Fatalf("unexpected result for a: %s", got[2].Data)
+	}
+}
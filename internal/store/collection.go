@@ -0,0 +1,79 @@
+package store
+
+import (
+	"errors"
+	"time"
+
+	"github.com/johannessarpola/poor-cache-go/internal/common"
+)
+
+// errUnchanged is returned by modify callbacks that left the value as it was,
+// so nothing is written.
+var errUnchanged = errors.New("unchanged")
+
+// modify changes the collection of the given kind stored under key. fn gets a
+// copy of the live value, or an empty one of that kind when the key is missing
+// or expired, so stored collections are never mutated in place. A collection
+// left empty by fn removes the key. New keys get the default ttl of the store,
+// existing ones keep their expiration.
+func (s *Store) modify(key, kind string, fn func(v *common.Value) error) error {
+	sh := s.shardFor(key)
+	now := time.Now()
+
+	defer s.evict()
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+
+	current, exists := sh.data[key]
+	exists = exists && !current.Expired(now)
+	var item common.Item
+	switch {
+	case exists && current.Value.Kind != kind:
+		return common.ErrWrongType
+	case exists:
+		item = current
+		item.Value = current.Value.Clone()
+	default:
+		item = newItem(nil, common.Meta{}, now, s.ttlFor(0))
+		item.Value.Kind = kind
+	}
+
+	err := fn(&item.Value)
+	if errors.Is(err, errUnchanged) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if item.Value.Empty() {
+		if !exists {
+			return nil
+		}
+		if err := s.logDelete(key); err != nil {
+			return err
+		}
+		sh.remove(key)
+		s.publishDelete("", key)
+		return nil
+	}
+	if s.maxBytes > 0 && item.Value.Size() > s.maxBytes {
+		return common.ErrTooLarge
+	}
+	item.Value.Meta.ModifiedAt = now
+	item.Value.Meta.AccessedAt = now
+	return s.commit(sh, key, &item)
+}
+
+// view returns the live collection of the given kind stored under key. The
+// value must not be modified. ok is false when the key is missing.
+func (s *Store) view(key, kind string) (common.Value, bool, error) {
+	item, exists := s.lookup(key)
+	if !exists {
+		return common.Value{}, false, nil
+	}
+	if item.Value.Kind != kind {
+		return common.Value{}, false, common.ErrWrongType
+	}
+	return item.Value, true, nil
+}
@@ -0,0 +1,88 @@
+package store
+
+import (
+	"encoding/json"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/johannessarpola/poor-cache-go/internal/common"
+)
+
+// Incr atomically adds delta to the integer stored under key and returns the
+// result. A missing key starts from zero and is created with ttl, an existing
+// one keeps its expiration. Values that are not integers are rejected with
+// common.ErrNotInteger.
+func (s *Store) Incr(key string, delta int64, ttl time.Duration) (int64, error) {
+	sh := s.shardFor(key)
+	now := time.Now()
+
+	defer s.evict()
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+
+	item := common.Item{Value: common.Value{Meta: common.Meta{CreatedAt: now}}}
+	var n int64
+	if current, exists := sh.data[key]; exists && !current.Expired(now) {
+		v, err := parseCounter(current)
+		if err != nil {
+			return 0, err
+		}
+		n = v
+		item = current
+	} else {
+		setExpiration(&item, now, s.ttlFor(ttl))
+	}
+
+	n, err := addInt64(n, delta)
+	if err != nil {
+		return 0, err
+	}
+
+	// Counters are plain decimal text, which is also valid JSON.
+	item.Value.Data = strconv.AppendInt(nil, n, 10)
+	item.Value.Meta.Codec = Raw.Name()
+	item.Value.Meta.ContentType = common.ContentTypeJSON
+	item.Value.Meta.ModifiedAt = now
+	item.Value.Meta.AccessedAt = now
+	if err := s.commit(sh, key, &item); err != nil {
+		return 0, err
+	}
+	return n, nil
+}
+
+// Decr atomically subtracts delta from the integer stored under key, see Incr.
+func (s *Store) Decr(key string, delta int64, ttl time.Duration) (int64, error) {
+	if delta == math.MinInt64 {
+		return 0, common.ErrOverflow
+	}
+	return s.Incr(key, -delta, ttl)
+}
+
+// addInt64 returns n+delta, or common.ErrOverflow when it does not fit.
+func addInt64(n, delta int64) (int64, error) {
+	if (delta > 0 && n > math.MaxInt64-delta) || (delta < 0 && n < math.MinInt64-delta) {
+		return 0, common.ErrOverflow
+	}
+	return n + delta, nil
+}
+
+func parseCounter(item common.Item) (int64, error) {
+	if item.Value.Kind != "" {
+		return 0, common.ErrWrongType
+	}
+	codec, err := CodecByName(item.Value.Meta.Codec)
+	if err != nil {
+		return 0, err
+	}
+	var raw json.RawMessage
+	if err := codec.Decode(item.Value.Data, &raw); err != nil {
+		return 0, common.ErrNotInteger
+	}
+	n, err := strconv.ParseInt(strings.TrimSpace(string(raw)), 10, 64)
+	if err != nil {
+		return 0, common.ErrNotInteger
+	}
+	return n, nil
+}
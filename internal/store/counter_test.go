@@ -0,0 +1,76 @@
+package store
+
+import (
+	"errors"
+	"math"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/johannessarpola/poor-cache-go/internal/common"
+)
+
+func TestIncrCreatesWithTTL(t *testing.T) {
+	s := New()
+	defer s.Close()
+
+	n, err := s.Incr("counter", 5, time.Minute)
+	if err != nil || n != 5 {
+		t.Fatalf("expected 5, got %d, %v", n, err)
+	}
+	if ttl, _ := s.TTL("counter"); ttl == common.NoExpiry {
+		t.Fatalf("expected a new counter to get the ttl")
+	}
+
+	n, _ = s.Decr("counter", 2, 0)
+	if n != 3 {
+		t.Fatalf("expected 3, got %d", n)
+	}
+
+	var v int
+	s.Get("counter", &v)
+	if v != 3 {
+		t.Fatalf("expected counter to read back as 3, got %d", v)
+	}
+}
+
+func TestIncrExistingJSONNumber(t *testing.T) {
+	s := New()
+	defer s.Close()
+
+	s.Set("number", 41, 0)
+	if n, err := s.Incr("number", 1, 0); err != nil || n != 42 {
+		t.Fatalf("expected 42, got %d, %v", n, err)
+	}
+
+	s.Set("text", "not a number", 0)
+	if _, err := s.Incr("text", 1, 0); !errors.Is(err, common.ErrNotInteger) {
+		t.Fatalf("expected ErrNotInteger, got %v", err)
+	}
+
+	s.Set("max", int64(math.MaxInt64), 0)
+	if _, err := s.Incr("max", 1, 0); !errors.Is(err, common.ErrOverflow) {
+		t.Fatalf("expected ErrOverflow, got %v", err)
+	}
+}
+
+func TestIncrConcurrent(t *testing.T) {
+	s := New()
+	defer s.Close()
+
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range 100 {
+				s.Incr("counter", 1, 0)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if n, _ := s.Incr("counter", 0, 0); n != 800 {
+		t.Fatalf("expected 800, got %d", n)
+	}
+}
@@ -0,0 +1,82 @@
+package store
+
+import (
+	"strings"
+	"time"
+
+	"github.com/johannessarpola/poor-cache-go/internal/common"
+)
+
+// deleteChunk is the number of keys removed per write lock, so bulk deletes
+// let readers of the same shard in between chunks.
+const deleteChunk = 128
+
+// DeletePrefix removes every key starting with prefix and returns the number
+// of keys removed.
+func (s *Store) DeletePrefix(prefix string) (int, error) {
+	return s.deleteWhere(func(key string) bool {
+		return strings.HasPrefix(key, prefix)
+	})
+}
+
+// DeleteMatching removes every key matching the glob pattern, see Scan for the
+// syntax, and returns the number of keys removed. An empty pattern matches
+// every key.
+func (s *Store) DeleteMatching(pattern string) (int, error) {
+	return s.deleteWhere(func(key string) bool {
+		return common.MatchGlob(pattern, key)
+	})
+}
+
+// deleteWhere removes the keys selected by match one shard at a time. Keys are
+// collected under the read lock and removed in chunks, so no lock is held for
+// longer than it takes to remove a chunk.
+func (s *Store) deleteWhere(match func(key string) bool) (int, error) {
+	n := 0
+	for _, sh := range s.shards {
+		var keys []string
+		sh.mu.RLock()
+		for key := range sh.data {
+			if match(key) {
+				keys = append(keys, key)
+			}
+		}
+		sh.mu.RUnlock()
+
+		for len(keys) > 0 {
+			chunk := keys[:min(deleteChunk, len(keys))]
+			keys = keys[len(chunk):]
+
+			removed, err := s.removeKeys(sh, chunk)
+			n += removed
+			if err != nil {
+				return n, err
+			}
+		}
+	}
+	return n, nil
+}
+
+// removeKeys removes keys from sh, publishes the deletion of the live ones and
+// returns their number. Expired keys are removed as well, as if cleaned up.
+func (s *Store) removeKeys(sh *shard, keys []string) (int, error) {
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+	now := time.Now()
+	n := 0
+	for _, key := range keys {
+		item, exists := sh.data[key]
+		if !exists {
+			continue // removed since it was collected
+		}
+		if err := s.logDelete(key); err != nil {
+			return n, err
+		}
+		sh.remove(key)
+		if !item.Expired(now) {
+			s.publishDelete("", key)
+			n++
+		}
+	}
+	return n, nil
+}
@@ -0,0 +1,57 @@
+package store
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestDeletePrefix(t *testing.T) {
+	s := New(WithShards(4))
+	defer s.Close()
+
+	for i := range 300 {
+		s.Set(fmt.Sprintf("product:%d", i), i, 0)
+	}
+	s.Set("productivity", 1, 0)
+	s.Set("user:1", 1, 0)
+
+	n, err := s.DeletePrefix("product:")
+	if err != nil || n != 300 {
+		t.Fatalf("expected 300 keys deleted, got %d (%v)", n, err)
+	}
+	if s.Has("product:42") || !s.Has("productivity") || !s.Has("user:1") {
+		t.Fatalf("expected only product: keys to be deleted")
+	}
+}
+
+func TestDeletePrefixSkipsExpired(t *testing.T) {
+	s := New()
+	defer s.Close()
+
+	s.Set("p:live", 1, 0)
+	s.Set("p:expired", 1, time.Nanosecond)
+	time.Sleep(time.Millisecond)
+
+	n, err := s.DeletePrefix("p:")
+	if err != nil || n != 1 {
+		t.Fatalf("expected 1 key deleted, got %d (%v)", n, err)
+	}
+}
+
+func TestDeleteMatching(t *testing.T) {
+	s := New()
+	defer s.Close()
+
+	s.Set("session:a:token", 1, 0)
+	s.Set("session:b:token", 1, 0)
+	s.Set("session:b:user", 1, 0)
+
+	n, err := s.DeleteMatching("session:*:token")
+	if err != nil || n != 2 {
+		t.Fatalf("expected 2 keys deleted, got %d (%v)", n, err)
+	}
+	if !s.Has("session:b:user") {
+		t.Fatalf("expected non-matching key to survive")
+	}
+}
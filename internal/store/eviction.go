@@ -0,0 +1,275 @@
+package store
+
+import (
+	"container/list"
+	"fmt"
+	"math/rand"
+	"sync"
+)
+
+// EvictionPolicy decides which key is dropped when the store grows past its
+// configured limits. The store serializes calls to the policy, so
+// implementations do not need to synchronize themselves.
+type EvictionPolicy interface {
+	// Added is called when a new key is inserted.
+	Added(key string)
+	// Accessed is called when an existing key is read or overwritten.
+	Accessed(key string)
+	// Removed is called when a key leaves the store for any reason.
+	Removed(key string)
+	// Victim returns the next key to evict, or false if nothing is tracked.
+	Victim() (string, bool)
+}
+
+var (
+	_ EvictionPolicy = (*LRU)(nil)
+	_ EvictionPolicy = (*LFU)(nil)
+	_ EvictionPolicy = (*FIFO)(nil)
+	_ EvictionPolicy = (*Random)(nil)
+)
+
+// PolicyByName returns the built-in policy registered under name.
+func PolicyByName(name string) (EvictionPolicy, error) {
+	switch name {
+	case "lru", "":
+		return NewLRU(), nil
+	case "lfu":
+		return NewLFU(), nil
+	case "fifo":
+		return NewFIFO(), nil
+	case "random":
+		return NewRandom(), nil
+	}
+	return nil, fmt.Errorf("unknown eviction policy %q", name)
+}
+
+// syncPolicy guards a policy shared by all shards of a store.
+type syncPolicy struct {
+	mu sync.Mutex
+	p  EvictionPolicy
+}
+
+func (sp *syncPolicy) Added(key string) {
+	sp.mu.Lock()
+	defer sp.mu.Unlock()
+	sp.p.Added(key)
+}
+
+func (sp *syncPolicy) Accessed(key string) {
+	sp.mu.Lock()
+	defer sp.mu.Unlock()
+	sp.p.Accessed(key)
+}
+
+func (sp *syncPolicy) Removed(key string) {
+	sp.mu.Lock()
+	defer sp.mu.Unlock()
+	sp.p.Removed(key)
+}
+
+func (sp *syncPolicy) Victim() (string, bool) {
+	sp.mu.Lock()
+	defer sp.mu.Unlock()
+	return sp.p.Victim()
+}
+
+// LRU evicts the least recently used key.
+type LRU struct {
+	order *list.List
+	elems map[string]*list.Element
+}
+
+func NewLRU() *LRU {
+	return &LRU{
+		order: list.New(),
+		elems: make(map[string]*list.Element),
+	}
+}
+
+func (p *LRU) Added(key string) {
+	if e, ok := p.elems[key]; ok {
+		p.order.MoveToFront(e)
+		return
+	}
+	p.elems[key] = p.order.PushFront(key)
+}
+
+func (p *LRU) Accessed(key string) {
+	if e, ok := p.elems[key]; ok {
+		p.order.MoveToFront(e)
+	}
+}
+
+func (p *LRU) Removed(key string) {
+	if e, ok := p.elems[key]; ok {
+		p.order.Remove(e)
+		delete(p.elems, key)
+	}
+}
+
+func (p *LRU) Victim() (string, bool) {
+	e := p.order.Back()
+	if e == nil {
+		return "", false
+	}
+	return e.Value.(string), true
+}
+
+// FIFO evicts the oldest inserted key regardless of how often it is used.
+type FIFO struct {
+	order *list.List
+	elems map[string]*list.Element
+}
+
+func NewFIFO() *FIFO {
+	return &FIFO{
+		order: list.New(),
+		elems: make(map[string]*list.Element),
+	}
+}
+
+func (p *FIFO) Added(key string) {
+	if _, ok := p.elems[key]; ok {
+		return
+	}
+	p.elems[key] = p.order.PushFront(key)
+}
+
+func (p *FIFO) Accessed(string) {}
+
+func (p *FIFO) Removed(key string) {
+	if e, ok := p.elems[key]; ok {
+		p.order.Remove(e)
+		delete(p.elems, key)
+	}
+}
+
+func (p *FIFO) Victim() (string, bool) {
+	e := p.order.Back()
+	if e == nil {
+		return "", false
+	}
+	return e.Value.(string), true
+}
+
+// LFU evicts the least frequently used key, breaking ties by recency.
+type LFU struct {
+	counts  map[string]int
+	buckets map[int]*list.List
+	elems   map[string]*list.Element
+	min     int
+}
+
+func NewLFU() *LFU {
+	return &LFU{
+		counts:  make(map[string]int),
+		buckets: make(map[int]*list.List),
+		elems:   make(map[string]*list.Element),
+	}
+}
+
+func (p *LFU) push(key string, count int) {
+	b, ok := p.buckets[count]
+	if !ok {
+		b = list.New()
+		p.buckets[count] = b
+	}
+	p.counts[key] = count
+	p.elems[key] = b.PushFront(key)
+}
+
+func (p *LFU) unlink(key string) int {
+	count := p.counts[key]
+	b := p.buckets[count]
+	b.Remove(p.elems[key])
+	if b.Len() == 0 {
+		delete(p.buckets, count)
+	}
+	return count
+}
+
+func (p *LFU) Added(key string) {
+	if _, ok := p.elems[key]; ok {
+		p.Accessed(key)
+		return
+	}
+	p.push(key, 1)
+	p.min = 1
+}
+
+func (p *LFU) Accessed(key string) {
+	if _, ok := p.elems[key]; !ok {
+		return
+	}
+	count := p.unlink(key)
+	if count == p.min && p.buckets[count] == nil {
+		p.min++
+	}
+	p.push(key, count+1)
+}
+
+func (p *LFU) Removed(key string) {
+	if _, ok := p.elems[key]; !ok {
+		return
+	}
+	count := p.unlink(key)
+	delete(p.counts, key)
+	delete(p.elems, key)
+	if count == p.min && p.buckets[count] == nil {
+		p.min = 0
+		for c := range p.buckets {
+			if p.min == 0 || c < p.min {
+				p.min = c
+			}
+		}
+	}
+}
+
+func (p *LFU) Victim() (string, bool) {
+	b, ok := p.buckets[p.min]
+	if !ok {
+		return "", false
+	}
+	return b.Back().Value.(string), true
+}
+
+// Random evicts a uniformly chosen key.
+type Random struct {
+	keys  []string
+	index map[string]int
+}
+
+func NewRandom() *Random {
+	return &Random{
+		index: make(map[string]int),
+	}
+}
+
+func (p *Random) Added(key string) {
+	if _, ok := p.index[key]; ok {
+		return
+	}
+	p.index[key] = len(p.keys)
+	p.keys = append(p.keys, key)
+}
+
+func (p *Random) Accessed(string) {}
+
+func (p *Random) Removed(key string) {
+	i, ok := p.index[key]
+	if !ok {
+		return
+	}
+	last := len(p.keys) - 1
+	p.keys[i] = p.keys[last]
+	p.index[p.keys[i]] = i
+	p.keys = p.keys[:last]
+	delete(p.index, key)
+}
+
+func (p *Random) Victim() (string, bool) {
+	if len(p.keys) == 0 {
+		return "", false
+	}
+	return p.keys[rand.Intn(len(p.keys))], true
+}
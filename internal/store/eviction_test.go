@@ -0,0 +1,111 @@
+package store
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLRUVictim(t *testing.T) {
+	p := NewLRU()
+	p.Added("a")
+	p.Added("b")
+	p.Added("c")
+	p.Accessed("a")
+
+	if v, _ := p.Victim(); v != "b" {
+		t.Fatalf("expected victim b, got %s", v)
+	}
+
+	p.Removed("b")
+	if v, _ := p.Victim(); v != "c" {
+		t.Fatalf("expected victim c, got %s", v)
+	}
+}
+
+func TestLFUVictim(t *testing.T) {
+	p := NewLFU()
+	p.Added("a")
+	p.Added("b")
+	p.Added("c")
+	p.Accessed("a")
+	p.Accessed("a")
+	p.Accessed("c")
+
+	if v, _ := p.Victim(); v != "b" {
+		t.Fatalf("expected victim b, got %s", v)
+	}
+
+	p.Removed("b")
+	if v, _ := p.Victim(); v != "c" {
+		t.Fatalf("expected victim c, got %s", v)
+	}
+}
+
+func TestFIFOVictim(t *testing.T) {
+	p := NewFIFO()
+	p.Added("a")
+	p.Added("b")
+	p.Accessed("a")
+
+	if v, _ := p.Victim(); v != "a" {
+		t.Fatalf("expected victim a, got %s", v)
+	}
+}
+
+func TestRandomVictim(t *testing.T) {
+	p := NewRandom()
+	if _, ok := p.Victim(); ok {
+		t.Fatalf("expected no victim from an empty policy")
+	}
+
+	p.Added("a")
+	p.Added("b")
+	p.Removed("a")
+	if v, _ := p.Victim(); v != "b" {
+		t.Fatalf("expected victim b, got %s", v)
+	}
+}
+
+func TestStoreMaxItems(t *testing.T) {
+	s := New(WithMaxItems(2))
+	defer s.Close()
+
+	s.Set("a", 1, time.Minute)
+	s.Set("b", 2, time.Minute)
+
+	var v int
+	s.Get("a", &v)
+	s.Set("c", 3, time.Minute)
+
+	if s.Has("b") {
+		t.Fatalf("expected b to be evicted")
+	}
+	if !s.Has("a") || !s.Has("c") {
+		t.Fatalf("expected a and c to be kept")
+	}
+}
+
+func TestStoreMaxBytes(t *testing.T) {
+	size := func(v any) int {
+		b, _ := Serialize(v)
+		return len(b)
+	}
+
+	s := New(WithMaxBytes(2*size("value")), WithEvictionPolicy(NewFIFO()))
+	defer s.Close()
+
+	s.Set("a", "value", time.Minute)
+	s.Set("b", "value", time.Minute)
+	s.Set("c", "value", time.Minute)
+
+	if s.Has("a") {
+		t.Fatalf("expected a to be evicted")
+	}
+	if !s.Has("b") || !s.Has("c") {
+		t.Fatalf("expected b and c to be kept")
+	}
+
+	if err := s.Set("d", "a value far too large for this store", time.Minute); err == nil {
+		t.Fatalf("expected an error for an oversized value")
+	}
+}
@@ -0,0 +1,53 @@
+package store
+
+import "github.com/johannessarpola/poor-cache-go/internal/common"
+
+// Flush removes every item from the store and returns how many were
+// discarded. All shards are locked while their data is replaced, so readers
+// see the store either full or empty, never partly flushed.
+func (s *Store) Flush() (int, error) {
+	for _, sh := range s.shards {
+		sh.mu.Lock()
+	}
+	defer func() {
+		for _, sh := range s.shards {
+			sh.mu.Unlock()
+		}
+	}()
+
+	if s.log != nil {
+		if err := s.logRecord(logRecord{Op: opFlush}); err != nil {
+			return 0, err
+		}
+	}
+
+	n := 0
+	for _, sh := range s.shards {
+		n += len(sh.data)
+		sh.clear()
+	}
+	s.publish(common.Event{Op: common.EventFlush})
+
+	// Keys queued for cleanup are gone now, save the cleanup the lookups.
+	for {
+		select {
+		case <-s.cleanupQueue:
+		default:
+			return n, nil
+		}
+	}
+}
+
+// clear removes every item of the shard. The caller must hold the write lock.
+func (sh *shard) clear() {
+	if sh.policy != nil {
+		for key := range sh.data {
+			sh.policy.Removed(key)
+		}
+	}
+	sh.usage.items.Add(-int64(len(sh.data)))
+	sh.usage.bytes.Add(-int64(sh.bytes))
+	sh.data = make(map[string]common.Item)
+	sh.accessed = make(map[string]*accessTime)
+	sh.bytes = 0
+}
@@ -0,0 +1,53 @@
+package store
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestFlush(t *testing.T) {
+	s := New(WithShards(4), WithMaxItems(10))
+	defer s.Close()
+
+	for _, key := range []string{"a", "b", "c"} {
+		s.Set(key, key, time.Minute)
+	}
+
+	n, err := s.Flush()
+	if err != nil || n != 3 {
+		t.Fatalf("expected 3 items flushed, got %d (%v)", n, err)
+	}
+	if s.Has("a") {
+		t.Fatalf("expected the store to be empty")
+	}
+
+	s.Set("a", "again", 0)
+	if !s.Has("a") {
+		t.Fatalf("expected writes after a flush to be kept")
+	}
+	for _, sh := range s.shards {
+		if sh.bytes != 0 && len(sh.data) == 0 {
+			t.Fatalf("expected size accounting to start over")
+		}
+	}
+	if items := s.usage.items.Load(); items != 1 {
+		t.Fatalf("expected 1 item in the store usage, got %d", items)
+	}
+}
+
+func TestFlushIsReplayed(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache.aof")
+
+	s := New(WithAppendLog(path, FsyncAlways, 0))
+	s.Set("before", 1, 0)
+	s.Flush()
+	s.Set("after", 1, 0)
+	s.Close()
+
+	restored := New(WithAppendLog(path, FsyncAlways, 0))
+	defer restored.Close()
+	if restored.Has("before") || !restored.Has("after") {
+		t.Fatalf("expected the flush to be replayed in order")
+	}
+}
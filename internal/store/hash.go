@@ -0,0 +1,97 @@
+package store
+
+import (
+	"maps"
+	"strconv"
+
+	"github.com/johannessarpola/poor-cache-go/internal/common"
+)
+
+// HSet sets fields of the hash stored under key, creating it if needed, and
+// returns the number of fields that did not exist before. Only the given
+// fields change; the hash keeps the ttl of its key.
+func (s *Store) HSet(key string, fields map[string]string) (int, error) {
+	added := 0
+	err := s.modify(key, common.KindHash, func(v *common.Value) error {
+		if len(fields) == 0 {
+			return errUnchanged
+		}
+		if v.Hash == nil {
+			v.Hash = make(map[string]string, len(fields))
+		}
+		for field, value := range fields {
+			if _, exists := v.Hash[field]; !exists {
+				added++
+			}
+			v.Hash[field] = value
+		}
+		return nil
+	})
+	return added, err
+}
+
+// HGet returns a field of the hash stored under key. ok is false when the key
+// or the field is missing.
+func (s *Store) HGet(key, field string) (string, bool, error) {
+	v, exists, err := s.view(key, common.KindHash)
+	if !exists {
+		return "", false, err
+	}
+	value, ok := v.Hash[field]
+	return value, ok, nil
+}
+
+// HGetAll returns all fields of the hash stored under key, or nil when the key
+// is missing.
+func (s *Store) HGetAll(key string) (map[string]string, error) {
+	v, exists, err := s.view(key, common.KindHash)
+	if !exists {
+		return nil, err
+	}
+	return maps.Clone(v.Hash), nil
+}
+
+// HDel removes fields from the hash stored under key and returns the number of
+// fields removed. Removing the last field removes the key.
+func (s *Store) HDel(key string, fields ...string) (int, error) {
+	removed := 0
+	err := s.modify(key, common.KindHash, func(v *common.Value) error {
+		for _, field := range fields {
+			if _, exists := v.Hash[field]; exists {
+				delete(v.Hash, field)
+				removed++
+			}
+		}
+		if removed == 0 {
+			return errUnchanged
+		}
+		return nil
+	})
+	return removed, err
+}
+
+// HIncrBy adds delta to the integer in a field of the hash stored under key
+// and returns the result. A missing field starts from zero.
+func (s *Store) HIncrBy(key, field string, delta int64) (int64, error) {
+	var n int64
+	err := s.modify(key, common.KindHash, func(v *common.Value) error {
+		if current, exists := v.Hash[field]; exists {
+			parsed, err := strconv.ParseInt(current, 10, 64)
+			if err != nil {
+				return common.ErrNotInteger
+			}
+			n = parsed
+		}
+		var err error
+		if n, err = addInt64(n, delta); err != nil {
+			return err
+		}
+
+		if v.Hash == nil {
+			v.Hash = make(map[string]string, 1)
+		}
+		v.Hash[field] = strconv.FormatInt(n, 10)
+		return nil
+	})
+	return n, err
+}
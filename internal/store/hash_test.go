@@ -0,0 +1,105 @@
+package store
+
+import (
+	"errors"
+	"maps"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/johannessarpola/poor-cache-go/internal/common"
+)
+
+func TestHashFields(t *testing.T) {
+	s := New()
+	defer s.Close()
+
+	if n, err := s.HSet("user:1", map[string]string{"name": "ada", "lang": "en"}); err != nil || n != 2 {
+		t.Fatalf("expected 2 new fields, got %d (%v)", n, err)
+	}
+	if n, _ := s.HSet("user:1", map[string]string{"lang": "fi"}); n != 0 {
+		t.Fatalf("expected an update not to count as new, got %d", n)
+	}
+	if v, ok, _ := s.HGet("user:1", "lang"); !ok || v != "fi" {
+		t.Fatalf("expected lang to be updated, got %q", v)
+	}
+	if _, ok, _ := s.HGet("user:1", "missing"); ok {
+		t.Fatalf("expected missing field to be reported")
+	}
+
+	all, _ := s.HGetAll("user:1")
+	if !maps.Equal(all, map[string]string{"name": "ada", "lang": "fi"}) {
+		t.Fatalf("unexpected fields: %v", all)
+	}
+
+	if n, _ := s.HIncrBy("user:1", "visits", 3); n != 3 {
+		t.Fatalf("expected visits to start from zero, got %d", n)
+	}
+	if _, err := s.HIncrBy("user:1", "name", 1); !errors.Is(err, common.ErrNotInteger) {
+		t.Fatalf("expected not an integer, got %v", err)
+	}
+
+	if n, _ := s.HDel("user:1", "name", "lang", "visits", "missing"); n != 3 {
+		t.Fatalf("expected 3 fields removed, got %d", n)
+	}
+	if s.Has("user:1") {
+		t.Fatalf("expected the key to go with its last field")
+	}
+}
+
+func TestHashSharesKeyTTL(t *testing.T) {
+	s := New()
+	defer s.Close()
+
+	s.HSet("h", map[string]string{"a": "1"})
+	s.Expire("h", time.Minute)
+	s.HSet("h", map[string]string{"b": "2"})
+	if ttl, _ := s.TTL("h"); ttl <= 0 {
+		t.Fatalf("expected field writes to keep the key's ttl, got %v", ttl)
+	}
+}
+
+func TestHashWrongType(t *testing.T) {
+	s := New()
+	defer s.Close()
+
+	s.Set("plain", "value", 0)
+	if _, err := s.HSet("plain", map[string]string{"a": "1"}); !errors.Is(err, common.ErrWrongType) {
+		t.Fatalf("expected wrong type on a plain key, got %v", err)
+	}
+
+	s.HSet("h", map[string]string{"a": "1"})
+	if _, _, err := s.GetBytes("h"); !errors.Is(err, common.ErrWrongType) {
+		t.Fatalf("expected wrong type reading a hash as bytes, got %v", err)
+	}
+	if _, err := s.Incr("h", 1, 0); !errors.Is(err, common.ErrWrongType) {
+		t.Fatalf("expected wrong type incrementing a hash, got %v", err)
+	}
+}
+
+func TestHashPersists(t *testing.T) {
+	dir := t.TempDir()
+	snap := filepath.Join(dir, "cache.snap")
+
+	s := New(WithSnapshot(snap, 0))
+	s.HSet("h", map[string]string{"a": "1"})
+	s.Close()
+
+	restored := New(WithSnapshot(snap, 0))
+	defer restored.Close()
+	if v, ok, _ := restored.HGet("h", "a"); !ok || v != "1" {
+		t.Fatalf("expected the hash to survive a snapshot, got %q", v)
+	}
+
+	aof := filepath.Join(dir, "cache.aof")
+	s = New(WithAppendLog(aof, FsyncAlways, 0))
+	s.HSet("h", map[string]string{"a": "1", "b": "2"})
+	s.HDel("h", "a")
+	s.Close()
+
+	replayed := New(WithAppendLog(aof, FsyncAlways, 0))
+	defer replayed.Close()
+	if all, _ := replayed.HGetAll("h"); !maps.Equal(all, map[string]string{"b": "2"}) {
+		t.Fatalf("expected the hash to be replayed, got %v", all)
+	}
+}
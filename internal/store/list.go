@@ -0,0 +1,206 @@
+package store
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"github.com/johannessarpola/poor-cache-go/internal/common"
+)
+
+// LPush inserts values at the head of the list stored under key, creating it
+// if needed, and returns the new length. Values are inserted one after the
+// other, so the last one ends up first.
+func (s *Store) LPush(key string, values ...string) (int, error) {
+	return s.push(key, values, func(list []string) []string {
+		head := slices.Clone(values)
+		slices.Reverse(head)
+		return append(head, list...)
+	})
+}
+
+// RPush appends values to the tail of the list stored under key, creating it
+// if needed, and returns the new length.
+func (s *Store) RPush(key string, values ...string) (int, error) {
+	return s.push(key, values, func(list []string) []string {
+		return append(list, values...)
+	})
+}
+
+func (s *Store) push(key string, values []string, add func(list []string) []string) (int, error) {
+	n := 0
+	err := s.modify(key, common.KindList, func(v *common.Value) error {
+		if len(values) == 0 {
+			n = len(v.List)
+			return errUnchanged
+		}
+		v.List = add(v.List)
+		n = len(v.List)
+		return nil
+	})
+	if err == nil && len(values) > 0 {
+		s.wakeWaiters(key)
+	}
+	return n, err
+}
+
+// LPop removes and returns the first value of the list stored under key. ok is
+// false when the list is empty or missing. Popping the last value removes the
+// key.
+func (s *Store) LPop(key string) (string, bool, error) {
+	return s.pop(key, true)
+}
+
+// RPop removes and returns the last value of the list stored under key, see
+// LPop.
+func (s *Store) RPop(key string) (string, bool, error) {
+	return s.pop(key, false)
+}
+
+func (s *Store) pop(key string, head bool) (value string, ok bool, err error) {
+	err = s.modify(key, common.KindList, func(v *common.Value) error {
+		if len(v.List) == 0 {
+			return errUnchanged
+		}
+		if head {
+			value, v.List = v.List[0], v.List[1:]
+		} else {
+			value, v.List = v.List[len(v.List)-1], v.List[:len(v.List)-1]
+		}
+		ok = true
+		return nil
+	})
+	return value, ok, err
+}
+
+// BLPop is LPop that waits until a value is pushed when the list is empty. It
+// gives up after timeout, or when ctx is done; a timeout of zero or less waits
+// on ctx alone. ok is false when no value arrived in time.
+func (s *Store) BLPop(ctx context.Context, key string, timeout time.Duration) (string, bool, error) {
+	return s.blockingPop(ctx, key, timeout, true)
+}
+
+// BRPop is RPop that waits until a value is pushed, see BLPop.
+func (s *Store) BRPop(ctx context.Context, key string, timeout time.Duration) (string, bool, error) {
+	return s.blockingPop(ctx, key, timeout, false)
+}
+
+func (s *Store) blockingPop(ctx context.Context, key string, timeout time.Duration, head bool) (string, bool, error) {
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	for {
+		value, ok, err := s.pop(key, head)
+		if ok || err != nil {
+			return value, ok, err
+		}
+
+		w, err := s.waitForPush(key)
+		if err != nil {
+			return "", false, err
+		}
+		if w == nil {
+			continue // pushed to since the pop
+		}
+		select {
+		case <-w.wake:
+			// Other waiters may take the value first, then wait again.
+		case <-ctx.Done():
+			s.stopWaiting(key, w)
+			if ctx.Err() == context.DeadlineExceeded {
+				return "", false, nil
+			}
+			return "", false, ctx.Err()
+		}
+	}
+}
+
+// pushWaiter is shared by everyone waiting for a push to the same list.
+type pushWaiter struct {
+	wake chan struct{} // closed on the next push
+	n    int
+}
+
+// waitForPush registers the caller as waiting for the next push to the list
+// stored under key, or returns nil when the list is not empty. Checking and
+// registering under the shard lock means no push can slip in between.
+func (s *Store) waitForPush(key string) (*pushWaiter, error) {
+	sh := s.shardFor(key)
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+
+	if item, exists := sh.data[key]; exists && !item.Expired(time.Now()) {
+		if item.Value.Kind != common.KindList {
+			return nil, common.ErrWrongType
+		}
+		if len(item.Value.List) > 0 {
+			return nil, nil
+		}
+	}
+	if sh.waiters == nil {
+		sh.waiters = make(map[string]*pushWaiter)
+	}
+	w, ok := sh.waiters[key]
+	if !ok {
+		w = &pushWaiter{wake: make(chan struct{})}
+		sh.waiters[key] = w
+	}
+	w.n++
+	return w, nil
+}
+
+// stopWaiting unregisters a caller that gave up waiting, forgetting the
+// waiter once nobody uses it.
+func (s *Store) stopWaiting(key string, w *pushWaiter) {
+	sh := s.shardFor(key)
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+	w.n--
+	if w.n == 0 && sh.waiters[key] == w {
+		delete(sh.waiters, key)
+	}
+}
+
+// wakeWaiters wakes up everyone waiting for a push to the list stored under
+// key.
+func (s *Store) wakeWaiters(key string) {
+	sh := s.shardFor(key)
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+	if w, ok := sh.waiters[key]; ok {
+		close(w.wake)
+		delete(sh.waiters, key)
+	}
+}
+
+// LRange returns the values of the list stored under key from start to stop,
+// both inclusive. Negative indexes count from the end, -1 being the last
+// value. Indexes past either end are clamped.
+func (s *Store) LRange(key string, start, stop int) ([]string, error) {
+	v, exists, err := s.view(key, common.KindList)
+	if !exists {
+		return nil, err
+	}
+
+	n := len(v.List)
+	if start < 0 {
+		start = max(n+start, 0)
+	}
+	if stop < 0 {
+		stop = n + stop
+	}
+	stop = min(stop, n-1)
+	if start > stop {
+		return []string{}, nil
+	}
+	return slices.Clone(v.List[start : stop+1]), nil
+}
+
+// LLen returns the length of the list stored under key, zero when missing.
+func (s *Store) LLen(key string) (int, error) {
+	v, _, err := s.view(key, common.KindList)
+	return len(v.List), err
+}
@@ -0,0 +1,104 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/johannessarpola/poor-cache-go/internal/common"
+)
+
+func TestListPushPop(t *testing.T) {
+	s := New()
+	defer s.Close()
+
+	if n, err := s.RPush("q", "b", "c"); err != nil || n != 2 {
+		t.Fatalf("expected length 2, got %d (%v)", n, err)
+	}
+	if n, _ := s.LPush("q", "a", "z"); n != 4 {
+		t.Fatalf("expected length 4, got %d", n)
+	}
+
+	all, _ := s.LRange("q", 0, -1)
+	if !slices.Equal(all, []string{"z", "a", "b", "c"}) {
+		t.Fatalf("unexpected list: %v", all)
+	}
+	if tail, _ := s.LRange("q", -2, 100); !slices.Equal(tail, []string{"b", "c"}) {
+		t.Fatalf("unexpected tail: %v", tail)
+	}
+	if none, _ := s.LRange("q", 3, 1); len(none) != 0 {
+		t.Fatalf("expected an empty range, got %v", none)
+	}
+
+	if v, ok, _ := s.LPop("q"); !ok || v != "z" {
+		t.Fatalf("expected z from the head, got %q", v)
+	}
+	if v, ok, _ := s.RPop("q"); !ok || v != "c" {
+		t.Fatalf("expected c from the tail, got %q", v)
+	}
+	if n, _ := s.LLen("q"); n != 2 {
+		t.Fatalf("expected length 2, got %d", n)
+	}
+
+	s.LPop("q")
+	s.LPop("q")
+	if _, ok, _ := s.LPop("q"); ok || s.Has("q") {
+		t.Fatalf("expected the key to go with its last value")
+	}
+
+	s.Set("plain", "value", 0)
+	if _, err := s.RPush("plain", "x"); !errors.Is(err, common.ErrWrongType) {
+		t.Fatalf("expected wrong type, got %v", err)
+	}
+}
+
+func TestBlockingPop(t *testing.T) {
+	s := New()
+	defer s.Close()
+
+	got := make(chan string)
+	go func() {
+		v, ok, err := s.BLPop(context.Background(), "jobs", time.Second)
+		if err != nil || !ok {
+			t.Errorf("expected a value, got %v", err)
+		}
+		got <- v
+	}()
+
+	time.Sleep(20 * time.Millisecond)
+	s.RPush("jobs", "job-1")
+
+	select {
+	case v := <-got:
+		if v != "job-1" {
+			t.Fatalf("expected job-1, got %q", v)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("blocked pop was not woken by the push")
+	}
+}
+
+func TestBlockingPopTimeout(t *testing.T) {
+	s := New()
+	defer s.Close()
+
+	start := time.Now()
+	_, ok, err := s.BRPop(context.Background(), "empty", 30*time.Millisecond)
+	if ok || err != nil {
+		t.Fatalf("expected a timeout without error, got %v", err)
+	}
+	if time.Since(start) < 30*time.Millisecond {
+		t.Fatalf("returned before the timeout")
+	}
+	if len(s.shardFor("empty").waiters) != 0 {
+		t.Fatalf("expected the waiter to be forgotten")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, _, err := s.BRPop(ctx, "empty", 0); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected the context error, got %v", err)
+	}
+}
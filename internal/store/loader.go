@@ -0,0 +1,178 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/johannessarpola/poor-cache-go/internal/common"
+)
+
+// maxLoadSize bounds the size of a value loaded from an origin.
+const maxLoadSize = 32 << 20
+
+const defaultLoadTimeout = 10 * time.Second
+
+// Loader fetches the value of a key that is missing from the store. id is the
+// key without the prefix the loader was registered for. It returns
+// common.ErrNotFound when the origin does not have the value either.
+type Loader interface {
+	Load(ctx context.Context, id string) (data []byte, contentType string, err error)
+}
+
+// HTTPLoader loads values with a GET from an origin URL built from a
+// template, in which {id} is replaced by the path-escaped id. A 404 from the
+// origin is a miss; any other status but 200 is an error.
+type HTTPLoader struct {
+	template string
+	client   *http.Client
+}
+
+// NewHTTPLoader returns a loader for the URL template that gives up on the
+// origin after timeout, or a default timeout when it is not positive.
+func NewHTTPLoader(template string, timeout time.Duration) *HTTPLoader {
+	if timeout <= 0 {
+		timeout = defaultLoadTimeout
+	}
+	return &HTTPLoader{template: template, client: &http.Client{Timeout: timeout}}
+}
+
+func (l *HTTPLoader) Load(ctx context.Context, id string) ([]byte, string, error) {
+	u := strings.ReplaceAll(l.template, "{id}", url.PathEscape(id))
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, "", fmt.Errorf("%w: %w", common.ErrLoad, err)
+	}
+	resp, err := l.client.Do(req)
+	if err != nil {
+		return nil, "", fmt.Errorf("%w: %w", common.ErrLoad, err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return nil, "", common.ErrNotFound
+	default:
+		return nil, "", fmt.Errorf("%w: origin responded %s", common.ErrLoad, resp.Status)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxLoadSize+1))
+	if err != nil {
+		return nil, "", fmt.Errorf("%w: %w", common.ErrLoad, err)
+	}
+	if len(data) > maxLoadSize {
+		return nil, "", common.ErrTooLarge
+	}
+	contentType := resp.Header.Get("Content-Type")
+	if contentType == "" {
+		contentType = common.ContentTypeBinary
+	}
+	return data, contentType, nil
+}
+
+type loaderRule struct {
+	prefix string
+	loader Loader
+	ttl    time.Duration
+}
+
+// WithLoader makes GetBytes load missing keys starting with prefix through
+// loader, caching what it returns for ttl. A zero ttl uses the default ttl of
+// the store. When several prefixes match a key the longest one wins.
+func WithLoader(prefix string, loader Loader, ttl time.Duration) Option {
+	return func(s *Store) {
+		s.loaders = append(s.loaders, loaderRule{prefix: prefix, loader: loader, ttl: ttl})
+	}
+}
+
+func (s *Store) loaderFor(key string) (loaderRule, bool) {
+	var found loaderRule
+	ok := false
+	for _, rule := range s.loaders {
+		if strings.HasPrefix(key, rule.prefix) && (!ok || len(rule.prefix) > len(found.prefix)) {
+			found, ok = rule, true
+		}
+	}
+	return found, ok
+}
+
+// load reads a missing key through its loader and caches the result. Misses
+// of the same key share a single origin call. A value written while loading
+// wins over the loaded one.
+func (s *Store) load(key string) ([]byte, *common.Meta, error) {
+	rule, ok := s.loaderFor(key)
+	if !ok {
+		return nil, nil, nil
+	}
+	return s.loads.do(key, func() ([]byte, *common.Meta, error) {
+		data, contentType, err := rule.loader.Load(context.Background(), strings.TrimPrefix(key, rule.prefix))
+		if errors.Is(err, common.ErrNotFound) {
+			return nil, nil, nil
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+
+		meta, err := s.SetNX(key, data, contentType, rule.ttl)
+		if errors.Is(err, common.ErrExists) {
+			item, exists := s.lookup(key)
+			if !exists {
+				return nil, nil, nil
+			}
+			return readBytes(item)
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		return data, meta, nil
+	})
+}
+
+// flights deduplicates concurrent loads of the same key.
+type flights struct {
+	mu    sync.Mutex
+	calls map[string]*flight
+}
+
+type flight struct {
+	done chan struct{}
+	data []byte
+	meta *common.Meta
+	err  error
+}
+
+// do runs fn for key unless a call for key is already running, in which case
+// it waits for that call and returns its result.
+func (f *flights) do(key string, fn func() ([]byte, *common.Meta, error)) ([]byte, *common.Meta, error) {
+	f.mu.Lock()
+	if c, ok := f.calls[key]; ok {
+		f.mu.Unlock()
+		<-c.done
+		if c.meta == nil {
+			return c.data, nil, c.err
+		}
+		meta := *c.meta
+		return c.data, &meta, c.err
+	}
+	c := &flight{done: make(chan struct{})}
+	if f.calls == nil {
+		f.calls = make(map[string]*flight)
+	}
+	f.calls[key] = c
+	f.mu.Unlock()
+
+	c.data, c.meta, c.err = fn()
+
+	f.mu.Lock()
+	delete(f.calls, key)
+	f.mu.Unlock()
+	close(c.done)
+	return c.data, c.meta, c.err
+}
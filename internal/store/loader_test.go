@@ -0,0 +1,110 @@
+package store
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/johannessarpola/poor-cache-go/internal/common"
+)
+
+// origin serves users/{id} as JSON, 404 for users/missing and 500 for
+// users/broken, counting the requests it gets.
+func origin(t *testing.T, delay time.Duration) (*httptest.Server, *atomic.Int32) {
+	var hits atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		time.Sleep(delay)
+		switch r.URL.Path {
+		case "/users/missing":
+			http.NotFound(w, r)
+		case "/users/broken":
+			w.WriteHeader(http.StatusInternalServerError)
+		default:
+			w.Header().Set("Content-Type", common.ContentTypeJSON)
+			w.Write([]byte(`{"path":"` + r.URL.Path + `"}`))
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &hits
+}
+
+func TestReadThroughLoader(t *testing.T) {
+	srv, hits := origin(t, 0)
+	s := New(WithLoader("user:", NewHTTPLoader(srv.URL+"/users/{id}", time.Second), time.Minute))
+	defer s.Close()
+
+	data, meta, err := s.GetBytes("user:42")
+	if err != nil || string(data) != `{"path":"/users/42"}` {
+		t.Fatalf("expected the origin value, got %s (%v)", data, err)
+	}
+	if meta.ContentType != common.ContentTypeJSON {
+		t.Fatalf("expected the origin content type, got %s", meta.ContentType)
+	}
+	if ttl, ok := s.TTL("user:42"); !ok || ttl <= 0 || ttl > time.Minute {
+		t.Fatalf("expected the loaded value to be cached with the loader ttl, got %v", ttl)
+	}
+	s.GetBytes("user:42")
+	if n := hits.Load(); n != 1 {
+		t.Fatalf("expected cached reads to skip the origin, got %d calls", n)
+	}
+
+	if data, meta, err := s.GetBytes("user:missing"); data != nil || meta != nil || err != nil {
+		t.Fatalf("expected an origin 404 to be a miss, got %s %v %v", data, meta, err)
+	}
+	if _, _, err := s.GetBytes("user:broken"); !errors.Is(err, common.ErrLoad) {
+		t.Fatalf("expected a load error, got %v", err)
+	}
+	if s.Has("user:missing") || s.Has("user:broken") {
+		t.Fatalf("expected failed loads not to be cached")
+	}
+
+	before := hits.Load()
+	if data, _, _ := s.GetBytes("product:1"); data != nil {
+		t.Fatalf("expected keys outside the prefix not to be loaded")
+	}
+	if hits.Load() != before {
+		t.Fatalf("expected no origin call for keys outside the prefix")
+	}
+}
+
+func TestLoaderDeduplicatesMisses(t *testing.T) {
+	srv, hits := origin(t, 50*time.Millisecond)
+	s := New(WithLoader("user:", NewHTTPLoader(srv.URL+"/users/{id}", time.Second), 0))
+	defer s.Close()
+
+	var wg sync.WaitGroup
+	for range 20 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if data, _, err := s.GetBytes("user:7"); err != nil || data == nil {
+				t.Errorf("expected the loaded value, got %s (%v)", data, err)
+			}
+		}()
+	}
+	wg.Wait()
+	if n := hits.Load(); n != 1 {
+		t.Fatalf("expected a single origin call, got %d", n)
+	}
+}
+
+func TestLoaderLongestPrefixWins(t *testing.T) {
+	srv, _ := origin(t, 0)
+	s := New(
+		WithLoader("", NewHTTPLoader(srv.URL+"/all/{id}", time.Second), 0),
+		WithLoader("user:", NewHTTPLoader(srv.URL+"/users/{id}", time.Second), 0),
+	)
+	defer s.Close()
+
+	if data, _, _ := s.GetBytes("user:a b"); string(data) != `{"path":"/users/a b"}` {
+		t.Fatalf("expected the user loader, got %s", data)
+	}
+	if data, _, _ := s.GetBytes("other"); string(data) != `{"path":"/all/other"}` {
+		t.Fatalf("expected the catch-all loader, got %s", data)
+	}
+}
@@ -0,0 +1,89 @@
+package store
+
+import (
+	"errors"
+	"maps"
+	"regexp"
+	"slices"
+	"sync"
+)
+
+// DefaultNamespace is the name of the store used when no namespace is given.
+const DefaultNamespace = "default"
+
+var (
+	ErrNamespaceExists = errors.New("namespace already exists")
+	ErrNamespaceName   = errors.New("namespace names may only contain letters, digits, '-' and '_'")
+)
+
+var namespaceName = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// Namespaces is a set of stores with isolated keyspaces, addressed by name.
+// Every namespace is a Store of its own, so it has its own data, limits,
+// eviction policy and default ttl.
+type Namespaces struct {
+	mu     sync.RWMutex
+	stores map[string]*Store
+}
+
+// NewNamespaces returns a set of namespaces holding def as DefaultNamespace.
+func NewNamespaces(def *Store) *Namespaces {
+	return &Namespaces{stores: map[string]*Store{DefaultNamespace: def}}
+}
+
+// Create adds a namespace with a new store built from opts.
+func (n *Namespaces) Create(name string, opts ...Option) (*Store, error) {
+	if !namespaceName.MatchString(name) {
+		return nil, ErrNamespaceName
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if _, exists := n.stores[name]; exists {
+		return nil, ErrNamespaceExists
+	}
+	s := New(opts...)
+	n.stores[name] = s
+	return s, nil
+}
+
+// Get returns the store of a namespace.
+func (n *Namespaces) Get(name string) (*Store, bool) {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+	s, ok := n.stores[name]
+	return s, ok
+}
+
+// Names returns the names of all namespaces in order.
+func (n *Namespaces) Names() []string {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+	return slices.Sorted(maps.Keys(n.stores))
+}
+
+// FlushAll flushes the stores of all namespaces in name order and returns the
+// number of items discarded. On failure the namespaces before have been
+// flushed already.
+func (n *Namespaces) FlushAll() (int, error) {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+	total := 0
+	for _, name := range slices.Sorted(maps.Keys(n.stores)) {
+		flushed, err := n.stores[name].Flush()
+		total += flushed
+		if err != nil {
+			return total, err
+		}
+	}
+	return total, nil
+}
+
+// Close closes the stores of all namespaces.
+func (n *Namespaces) Close() {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+	for _, s := range n.stores {
+		s.Close()
+	}
+}
@@ -0,0 +1,79 @@
+package store
+
+import (
+	"errors"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestNamespacesAreIsolated(t *testing.T) {
+	ns := NewNamespaces(New())
+	defer ns.Close()
+
+	sessions, err := ns.Create("sessions", WithDefaultTTL(time.Minute), WithMaxItems(1))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	def, _ := ns.Get(DefaultNamespace)
+
+	def.Set("user:1", "default", 0)
+	sessions.Set("user:1", "session", 0)
+
+	var v string
+	if def.Get("user:1", &v); v != "default" {
+		t.Fatalf("expected the default namespace to keep its value, got %q", v)
+	}
+	if ttl, _ := def.TTL("user:1"); ttl != -1 {
+		t.Fatalf("expected no default ttl outside sessions, got %v", ttl)
+	}
+	if ttl, _ := sessions.TTL("user:1"); ttl <= 0 || ttl > time.Minute {
+		t.Fatalf("expected the sessions default ttl, got %v", ttl)
+	}
+
+	sessions.Set("user:2", "session", -1)
+	if sessions.Has("user:1") || !def.Has("user:1") {
+		t.Fatalf("expected eviction in sessions to leave the default namespace alone")
+	}
+	if ttl, _ := sessions.TTL("user:2"); ttl != -1 {
+		t.Fatalf("expected a negative ttl to override the default, got %v", ttl)
+	}
+
+	if names := ns.Names(); !slices.Equal(names, []string{"default", "sessions"}) {
+		t.Fatalf("unexpected namespaces: %v", names)
+	}
+}
+
+func TestNamespacesCreate(t *testing.T) {
+	ns := NewNamespaces(New())
+	defer ns.Close()
+
+	if _, err := ns.Create(DefaultNamespace); !errors.Is(err, ErrNamespaceExists) {
+		t.Fatalf("expected default to exist already, got %v", err)
+	}
+	if _, err := ns.Create("no/slashes"); !errors.Is(err, ErrNamespaceName) {
+		t.Fatalf("expected an invalid name error, got %v", err)
+	}
+	if _, ok := ns.Get("missing"); ok {
+		t.Fatalf("expected missing namespace to be unknown")
+	}
+}
+
+func TestNamespacesFlushAll(t *testing.T) {
+	ns := NewNamespaces(New())
+	defer ns.Close()
+
+	def, _ := ns.Get(DefaultNamespace)
+	sessions, _ := ns.Create("sessions")
+	def.Set("a", 1, time.Minute)
+	sessions.Set("b", 2, time.Minute)
+	sessions.Set("c", 3, time.Minute)
+
+	n, err := ns.FlushAll()
+	if err != nil || n != 3 {
+		t.Fatalf("expected 3 items flushed, got %d, %v", n, err)
+	}
+	if def.Has("a") || sessions.Has("b") || sessions.Has("c") {
+		t.Fatalf("expected every namespace to be empty")
+	}
+}
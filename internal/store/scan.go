@@ -0,0 +1,94 @@
+package store
+
+import (
+	"encoding/base64"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/johannessarpola/poor-cache-go/internal/common"
+)
+
+const (
+	defaultScanCount = 10
+	maxScanCount     = 1000
+)
+
+// Scan returns up to count live keys matching the glob pattern, starting at
+// cursor, together with the cursor of the next page. Start with an empty
+// cursor; an empty next cursor means the scan is complete. Only one shard is
+// locked at a time, so writers are never blocked for the whole scan. Keys that
+// exist for the whole scan are returned exactly once; keys written or removed
+// while scanning may or may not be.
+//
+// Patterns support * (any run of characters), ? (any single byte) and \
+// to escape either. An empty pattern matches every key.
+func (s *Store) Scan(pattern, cursor string, count int) ([]string, string, error) {
+	if count <= 0 {
+		count = defaultScanCount
+	}
+	count = min(count, maxScanCount)
+
+	shard, after, err := decodeCursor(cursor, len(s.shards))
+	if err != nil {
+		return nil, "", err
+	}
+
+	var keys []string
+	for ; shard < len(s.shards); shard, after = shard+1, "" {
+		page, more := s.shards[shard].scan(pattern, after, count-len(keys), time.Now())
+		keys = append(keys, page...)
+		if more {
+			return keys, encodeCursor(shard, keys[len(keys)-1]), nil
+		}
+		if len(keys) == count && shard+1 < len(s.shards) {
+			return keys, encodeCursor(shard+1, ""), nil
+		}
+	}
+	return keys, "", nil
+}
+
+// scan returns up to count live keys of the shard matching pattern that sort
+// after the given key, in order, and whether there are more.
+func (sh *shard) scan(pattern, after string, count int, now time.Time) ([]string, bool) {
+	sh.mu.RLock()
+	var keys []string
+	for key, item := range sh.data {
+		if key > after && !item.Expired(now) && common.MatchGlob(pattern, key) {
+			keys = append(keys, key)
+		}
+	}
+	sh.mu.RUnlock()
+
+	slices.Sort(keys)
+	if len(keys) > count {
+		return keys[:count], true
+	}
+	return keys, false
+}
+
+// A cursor is the shard to continue in and the last key returned from it. It
+// is encoded so that clients treat it as opaque.
+func encodeCursor(shard int, after string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.Itoa(shard) + ":" + after))
+}
+
+func decodeCursor(cursor string, shards int) (int, string, error) {
+	if cursor == "" {
+		return 0, "", nil
+	}
+	b, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, "", common.ErrInvalidCursor
+	}
+	idx, after, ok := strings.Cut(string(b), ":")
+	if !ok {
+		return 0, "", common.ErrInvalidCursor
+	}
+	shard, err := strconv.Atoi(idx)
+	if err != nil || shard < 0 || shard >= shards {
+		return 0, "", common.ErrInvalidCursor
+	}
+	return shard, after, nil
+}
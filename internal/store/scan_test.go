@@ -0,0 +1,62 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/johannessarpola/poor-cache-go/internal/common"
+)
+
+func TestScanPagesThroughAllKeys(t *testing.T) {
+	s := New(WithShards(4))
+	defer s.Close()
+
+	var want []string
+	for i := range 25 {
+		key := fmt.Sprintf("user:%02d", i)
+		want = append(want, key)
+		s.Set(key, i, 0)
+	}
+	s.Set("product:1", 1, 0)
+	s.Set("user:expired", 1, time.Nanosecond)
+	time.Sleep(time.Millisecond)
+
+	var got []string
+	cursor := ""
+	for pages := 0; ; pages++ {
+		if pages > 25 {
+			t.Fatalf("scan did not terminate")
+		}
+		keys, next, err := s.Scan("user:*", cursor, 7)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(keys) > 7 {
+			t.Fatalf("page larger than count: %d", len(keys))
+		}
+		got = append(got, keys...)
+		if next == "" {
+			break
+		}
+		cursor = next
+	}
+
+	slices.Sort(got)
+	if !slices.Equal(got, want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+}
+
+func TestScanInvalidCursor(t *testing.T) {
+	s := New()
+	defer s.Close()
+
+	for _, cursor := range []string{"not base64!", encodeCursor(999, "")} {
+		if _, _, err := s.Scan("", cursor, 10); !errors.Is(err, common.ErrInvalidCursor) {
+			t.Fatalf("expected invalid cursor for %q, got %v", cursor, err)
+		}
+	}
+}
@@ -2,38 +2,186 @@ package store
 
 import (
 	"bytes"
+	"compress/gzip"
 	"compress/zlib"
 	"encoding/json"
 	"fmt"
+	"io"
+	"sync"
 )
 
+// Codec turns values into the bytes kept in the store and back. The name of
+// the codec used is recorded with every item, so items written with different
+// codecs can live in the same store.
+type Codec interface {
+	Name() string
+	Encode(value any) ([]byte, error)
+	Decode(data []byte, dest any) error
+}
+
+// Compressor is implemented by codecs that compress the output of another
+// codec. Stores use it to skip compression for values below a size threshold.
+type Compressor interface {
+	Codec
+	Uncompressed() Codec
+	Compress(data []byte) ([]byte, error)
+}
+
+var (
+	Raw      Codec = rawCodec{}
+	JSON     Codec = jsonCodec{}
+	JSONZlib Codec = &compressedCodec{name: "json+zlib", base: JSON, newWriter: newZlibWriter, newReader: zlib.NewReader}
+	JSONGzip Codec = &compressedCodec{name: "json+gzip", base: JSON, newWriter: newGzipWriter, newReader: newGzipReader}
+	RawZlib  Codec = &compressedCodec{name: "raw+zlib", base: Raw, newWriter: newZlibWriter, newReader: zlib.NewReader}
+	RawGzip  Codec = &compressedCodec{name: "raw+gzip", base: Raw, newWriter: newGzipWriter, newReader: newGzipReader}
+)
+
+// rawCompressors maps compressing codecs to the codec with the same
+// compression over raw bytes, used for values written with SetBytes.
+var rawCompressors = map[Codec]Compressor{
+	JSONZlib: RawZlib.(Compressor),
+	JSONGzip: RawGzip.(Compressor),
+}
+
+func newZlibWriter(w io.Writer) io.WriteCloser { return zlib.NewWriter(w) }
+
+func newGzipWriter(w io.Writer) io.WriteCloser { return gzip.NewWriter(w) }
+
+func newGzipReader(r io.Reader) (io.ReadCloser, error) { return gzip.NewReader(r) }
+
+// DefaultCodec is used by stores without WithCodec, and for items that were
+// written before codecs were recorded.
+var DefaultCodec = JSONZlib
+
+var (
+	codecsMu sync.RWMutex
+	codecs   = map[string]Codec{}
+)
+
+func init() {
+	for _, c := range []Codec{Raw, JSON, JSONZlib, JSONGzip, RawZlib, RawGzip} {
+		RegisterCodec(c)
+	}
+}
+
+// RegisterCodec makes a codec available for decoding items that name it.
+func RegisterCodec(c Codec) {
+	codecsMu.Lock()
+	defer codecsMu.Unlock()
+	codecs[c.Name()] = c
+}
+
+// CodecByName returns the registered codec called name. An empty name refers
+// to DefaultCodec.
+func CodecByName(name string) (Codec, error) {
+	if name == "" {
+		return DefaultCodec, nil
+	}
+	codecsMu.RLock()
+	defer codecsMu.RUnlock()
+	c, ok := codecs[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown codec %q", name)
+	}
+	return c, nil
+}
+
+// Serialize encodes value with the default codec.
 func Serialize(value any) ([]byte, error) {
-	var compressed bytes.Buffer
-	writer := zlib.NewWriter(&compressed)
+	return DefaultCodec.Encode(value)
+}
 
-	encoder := json.NewEncoder(writer)
+// Deserialize decodes data written by Serialize.
+func Deserialize(data []byte, dest any) error {
+	return DefaultCodec.Decode(data, dest)
+}
 
-	err := encoder.Encode(value)
-	if err != nil {
-		return nil, err
+// rawCodec stores bytes as they are. It only accepts byte-like values.
+type rawCodec struct{}
+
+func (rawCodec) Name() string { return "raw" }
+
+func (rawCodec) Encode(value any) ([]byte, error) {
+	switch v := value.(type) {
+	case []byte:
+		return v, nil
+	case json.RawMessage:
+		return v, nil
+	case string:
+		return []byte(v), nil
 	}
+	return nil, fmt.Errorf("raw codec cannot encode %T", value)
+}
+
+func (rawCodec) Decode(data []byte, dest any) error {
+	switch d := dest.(type) {
+	case *[]byte:
+		*d = bytes.Clone(data)
+	case *json.RawMessage:
+		*d = bytes.Clone(data)
+	case *string:
+		*d = string(data)
+	default:
+		// Raw values are often JSON, let callers decode them as such.
+		return json.Unmarshal(data, dest)
+	}
+	return nil
+}
 
-	err = writer.Close()
+type jsonCodec struct{}
+
+func (jsonCodec) Name() string { return "json" }
+
+func (jsonCodec) Encode(value any) ([]byte, error) {
+	return json.Marshal(value)
+}
+
+func (jsonCodec) Decode(data []byte, dest any) error {
+	return json.Unmarshal(data, dest)
+}
+
+// compressedCodec compresses the output of a base codec.
+type compressedCodec struct {
+	name      string
+	base      Codec
+	newWriter func(io.Writer) io.WriteCloser
+	newReader func(io.Reader) (io.ReadCloser, error)
+}
+
+func (c *compressedCodec) Name() string { return c.name }
+
+func (c *compressedCodec) Uncompressed() Codec { return c.base }
+
+func (c *compressedCodec) Encode(value any) ([]byte, error) {
+	data, err := c.base.Encode(value)
 	if err != nil {
-		fmt.Println("Error closing zlib writer:", err)
 		return nil, err
 	}
+	return c.Compress(data)
+}
 
+func (c *compressedCodec) Compress(data []byte) ([]byte, error) {
+	var compressed bytes.Buffer
+	writer := c.newWriter(&compressed)
+	if _, err := writer.Write(data); err != nil {
+		return nil, err
+	}
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
 	return compressed.Bytes(), nil
 }
 
-func Deserialize(data []byte, dest any) error {
-	// Decompress the JSON data
-	reader, err := zlib.NewReader(bytes.NewReader(data))
+func (c *compressedCodec) Decode(data []byte, dest any) error {
+	reader, err := c.newReader(bytes.NewReader(data))
 	if err != nil {
 		return err
 	}
 	defer reader.Close()
 
-	return json.NewDecoder(reader).Decode(dest)
+	decompressed, err := io.ReadAll(reader)
+	if err != nil {
+		return err
+	}
+	return c.base.Decode(decompressed, dest)
 }
@@ -1,7 +1,10 @@
 package store
 
 import (
+	"bytes"
+	"strings"
 	"testing"
+	"time"
 )
 
 type TestStruct struct {
@@ -68,3 +71,106 @@ func TestSerializeDeserializeRoundTrip(t *testing.T) {
 		t.Fatalf("Round trip failed: deserialized data does not match original data")
 	}
 }
+
+func TestCodecsRoundTrip(t *testing.T) {
+	value := TestStruct{
+		Field1: "hello",
+		Field2: 42,
+	}
+
+	for _, c := range []Codec{JSON, JSONZlib, JSONGzip} {
+		t.Run(c.Name(), func(t *testing.T) {
+			encoded, err := c.Encode(value)
+			if err != nil {
+				t.Fatalf("Encode failed: %v", err)
+			}
+
+			var decoded TestStruct
+			if err := c.Decode(encoded, &decoded); err != nil {
+				t.Fatalf("Decode failed: %v", err)
+			}
+			if decoded != value {
+				t.Fatalf("Round trip failed: %#v", decoded)
+			}
+		})
+	}
+}
+
+func TestRawCodec(t *testing.T) {
+	data := []byte{0x00, 0xff, 0x10}
+
+	encoded, err := Raw.Encode(data)
+	if err != nil {
+		t.Fatalf("Encode failed: %v", err)
+	}
+
+	var decoded []byte
+	if err := Raw.Decode(encoded, &decoded); err != nil {
+		t.Fatalf("Decode failed: %v", err)
+	}
+	if string(decoded) != string(data) {
+		t.Fatalf("Round trip failed: %v", decoded)
+	}
+
+	if _, err := Raw.Encode(42); err == nil {
+		t.Fatalf("expected raw codec to reject non-byte values")
+	}
+}
+
+func TestCompressionThreshold(t *testing.T) {
+	s := New(WithCodec(JSONGzip), WithCompressionThreshold(64))
+	defer s.Close()
+
+	s.Set("small", "tiny", time.Minute)
+	s.Set("large", strings.Repeat("large ", 64), time.Minute)
+
+	var small, large string
+	smallMeta, _ := s.Get("small", &small)
+	largeMeta, _ := s.Get("large", &large)
+
+	if smallMeta.Codec != "json" || small != "tiny" {
+		t.Fatalf("expected small value stored as json, got %s", smallMeta.Codec)
+	}
+	if largeMeta.Codec != "json+gzip" || large != strings.Repeat("large ", 64) {
+		t.Fatalf("expected large value stored as json+gzip, got %s", largeMeta.Codec)
+	}
+}
+
+func TestMixedCodecs(t *testing.T) {
+	s := New(WithShards(1))
+	defer s.Close()
+	s.Set("zlib", "old", time.Minute)
+
+	s.codec = JSON
+	s.Set("json", "new", time.Minute)
+
+	var a, b string
+	if _, err := s.Get("zlib", &a); err != nil || a != "old" {
+		t.Fatalf("could not read zlib item: %v", err)
+	}
+	if _, err := s.Get("json", &b); err != nil || b != "new" {
+		t.Fatalf("could not read json item: %v", err)
+	}
+}
+
+func TestCompressedBytes(t *testing.T) {
+	s := New(WithCodec(JSONZlib), WithCompressionThreshold(64))
+	defer s.Close()
+
+	binary := append([]byte{0x00, 0xff}, bytes.Repeat([]byte("large "), 64)...)
+	s.SetBytes("small", []byte{0x00, 0xff}, "", time.Minute)
+	s.SetBytes("large", binary, "", time.Minute)
+
+	small, smallMeta, _ := s.GetBytes("small")
+	large, largeMeta, _ := s.GetBytes("large")
+
+	if smallMeta.Codec != "raw" || !bytes.Equal(small, []byte{0x00, 0xff}) {
+		t.Fatalf("expected small value stored as raw, got %s", smallMeta.Codec)
+	}
+	if largeMeta.Codec != "raw+zlib" || !bytes.Equal(large, binary) {
+		t.Fatalf("expected large value stored as raw+zlib, got %s", largeMeta.Codec)
+	}
+	if stored, _ := s.lookup("large"); len(stored.Value.Data) >= len(binary) {
+		t.Fatalf("expected large value to be compressed")
+	}
+}
@@ -0,0 +1,153 @@
+package store
+
+import (
+	"slices"
+
+	"github.com/johannessarpola/poor-cache-go/internal/common"
+)
+
+// Sets are kept as sorted slices of members, so membership is a binary search
+// and members come out in a stable order.
+
+// SAdd adds members to the set stored under key, creating it if needed, and
+// returns the number of members that were new.
+func (s *Store) SAdd(key string, members ...string) (int, error) {
+	added := 0
+	err := s.modify(key, common.KindSet, func(v *common.Value) error {
+		for _, member := range members {
+			if i, found := slices.BinarySearch(v.Set, member); !found {
+				v.Set = slices.Insert(v.Set, i, member)
+				added++
+			}
+		}
+		if added == 0 {
+			return errUnchanged
+		}
+		return nil
+	})
+	return added, err
+}
+
+// SRem removes members from the set stored under key and returns the number
+// removed. Removing the last member removes the key.
+func (s *Store) SRem(key string, members ...string) (int, error) {
+	removed := 0
+	err := s.modify(key, common.KindSet, func(v *common.Value) error {
+		for _, member := range members {
+			if i, found := slices.BinarySearch(v.Set, member); found {
+				v.Set = slices.Delete(v.Set, i, i+1)
+				removed++
+			}
+		}
+		if removed == 0 {
+			return errUnchanged
+		}
+		return nil
+	})
+	return removed, err
+}
+
+// SIsMember reports whether member is in the set stored under key.
+func (s *Store) SIsMember(key, member string) (bool, error) {
+	v, _, err := s.view(key, common.KindSet)
+	_, found := slices.BinarySearch(v.Set, member)
+	return found, err
+}
+
+// SMembers returns the members of the set stored under key in order, or nil
+// when the key is missing.
+func (s *Store) SMembers(key string) ([]string, error) {
+	v, exists, err := s.view(key, common.KindSet)
+	if !exists {
+		return nil, err
+	}
+	return slices.Clone(v.Set), nil
+}
+
+// SCard returns the number of members of the set stored under key, zero when
+// missing.
+func (s *Store) SCard(key string) (int, error) {
+	v, _, err := s.view(key, common.KindSet)
+	return len(v.Set), err
+}
+
+// SInter returns the members present in all sets stored under keys, in order.
+// Missing keys count as empty sets. Each set is read on its own, so a set
+// written to meanwhile may be seen before or after the write.
+func (s *Store) SInter(keys ...string) ([]string, error) {
+	sets, err := s.sets(keys)
+	if err != nil || len(sets) == 0 {
+		return []string{}, err
+	}
+	return filterSet(sets[0], func(member string) bool {
+		return inAll(sets[1:], member)
+	}), nil
+}
+
+// SUnion returns the members present in any of the sets stored under keys, in
+// order, see SInter.
+func (s *Store) SUnion(keys ...string) ([]string, error) {
+	sets, err := s.sets(keys)
+	if err != nil {
+		return []string{}, err
+	}
+	union := []string{}
+	for _, set := range sets {
+		union = append(union, set...)
+	}
+	slices.Sort(union)
+	return slices.Compact(union), nil
+}
+
+// SDiff returns the members of the set stored under the first key that are in
+// none of the others, in order, see SInter.
+func (s *Store) SDiff(keys ...string) ([]string, error) {
+	sets, err := s.sets(keys)
+	if err != nil || len(sets) == 0 {
+		return []string{}, err
+	}
+	return filterSet(sets[0], func(member string) bool {
+		return !inAny(sets[1:], member)
+	}), nil
+}
+
+// sets returns the sets stored under keys, empty for missing keys.
+func (s *Store) sets(keys []string) ([][]string, error) {
+	sets := make([][]string, len(keys))
+	for i, key := range keys {
+		v, _, err := s.view(key, common.KindSet)
+		if err != nil {
+			return nil, err
+		}
+		sets[i] = v.Set
+	}
+	return sets, nil
+}
+
+func filterSet(set []string, keep func(member string) bool) []string {
+	result := []string{}
+	for _, member := range set {
+		if keep(member) {
+			result = append(result, member)
+		}
+	}
+	return result
+}
+
+func inAll(sets [][]string, member string) bool {
+	for _, set := range sets {
+		if _, found := slices.BinarySearch(set, member); !found {
+			return false
+		}
+	}
+	return true
+}
+
+func inAny(sets [][]string, member string) bool {
+	for _, set := range sets {
+		if _, found := slices.BinarySearch(set, member); found {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,87 @@
+package store
+
+import (
+	"errors"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/johannessarpola/poor-cache-go/internal/common"
+)
+
+func TestSetMembers(t *testing.T) {
+	s := New()
+	defer s.Close()
+
+	if n, err := s.SAdd("flags", "b", "a", "b", "c"); err != nil || n != 3 {
+		t.Fatalf("expected 3 new members, got %d (%v)", n, err)
+	}
+	if members, _ := s.SMembers("flags"); !slices.Equal(members, []string{"a", "b", "c"}) {
+		t.Fatalf("expected sorted members, got %v", members)
+	}
+	if ok, _ := s.SIsMember("flags", "b"); !ok {
+		t.Fatalf("expected b to be a member")
+	}
+	if ok, _ := s.SIsMember("missing", "b"); ok {
+		t.Fatalf("expected no members in a missing set")
+	}
+	if n, _ := s.SRem("flags", "a", "z"); n != 1 {
+		t.Fatalf("expected 1 member removed, got %d", n)
+	}
+	if n, _ := s.SCard("flags"); n != 2 {
+		t.Fatalf("expected 2 members left, got %d", n)
+	}
+	s.SRem("flags", "b", "c")
+	if s.Has("flags") {
+		t.Fatalf("expected the key to go with its last member")
+	}
+}
+
+func TestSetAlgebra(t *testing.T) {
+	s := New()
+	defer s.Close()
+
+	s.SAdd("x", "a", "b", "c", "d")
+	s.SAdd("y", "b", "c", "e")
+	s.SAdd("z", "c", "d")
+
+	tests := []struct {
+		name string
+		op   func(keys ...string) ([]string, error)
+		keys []string
+		want []string
+	}{
+		{"inter", s.SInter, []string{"x", "y", "z"}, []string{"c"}},
+		{"inter with missing", s.SInter, []string{"x", "missing"}, []string{}},
+		{"union", s.SUnion, []string{"y", "z", "missing"}, []string{"b", "c", "d", "e"}},
+		{"diff", s.SDiff, []string{"x", "y"}, []string{"a", "d"}},
+		{"diff of one", s.SDiff, []string{"z"}, []string{"c", "d"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.op(tt.keys...)
+			if err != nil || !slices.Equal(got, tt.want) {
+				t.Fatalf("expected %v, got %v (%v)", tt.want, got, err)
+			}
+		})
+	}
+
+	s.Set("plain", "v", 0)
+	if _, err := s.SUnion("x", "plain"); !errors.Is(err, common.ErrWrongType) {
+		t.Fatalf("expected wrong type, got %v", err)
+	}
+}
+
+func TestSetPersists(t *testing.T) {
+	aof := filepath.Join(t.TempDir(), "cache.aof")
+	s := New(WithAppendLog(aof, FsyncAlways, 0))
+	s.SAdd("tags", "go", "cache", "udp")
+	s.SRem("tags", "udp")
+	s.Close()
+
+	replayed := New(WithAppendLog(aof, FsyncAlways, 0))
+	defer replayed.Close()
+	if members, _ := replayed.SMembers("tags"); !slices.Equal(members, []string{"cache", "go"}) {
+		t.Fatalf("expected the set to be replayed, got %v", members)
+	}
+}
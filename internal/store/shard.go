@@ -0,0 +1,137 @@
+package store
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/johannessarpola/poor-cache-go/internal/common"
+)
+
+// shard is an independently locked part of the keyspace. Limits and the
+// eviction policy apply to the whole store, so every shard shares them.
+type shard struct {
+	mu       sync.RWMutex
+	data     map[string]common.Item
+	accessed map[string]*accessTime // updated by readers under the read lock
+	bytes    int
+	usage    *usage
+	policy   EvictionPolicy
+	waiters  map[string]*pushWaiter // blocked pops by list key
+}
+
+// usage is the size of the whole store, kept up to date by its shards.
+type usage struct {
+	items atomic.Int64
+	bytes atomic.Int64
+}
+
+// accessTime is the last access of a key. Readers holding only the shard's
+// read lock update it, so it is atomic.
+type accessTime struct {
+	nanos atomic.Int64 // zero when never accessed
+}
+
+func newAccessTime(t time.Time) *accessTime {
+	a := &accessTime{}
+	a.advance(t)
+	return a
+}
+
+// swap records an access at t and returns the previous one.
+func (a *accessTime) swap(t time.Time) time.Time {
+	return fromNanos(a.nanos.Swap(t.UnixNano()))
+}
+
+// advance moves the access time to t unless a later access was recorded.
+func (a *accessTime) advance(t time.Time) {
+	if t.IsZero() {
+		return
+	}
+	for {
+		current := a.nanos.Load()
+		if t.UnixNano() <= current || a.nanos.CompareAndSwap(current, t.UnixNano()) {
+			return
+		}
+	}
+}
+
+func (a *accessTime) load() time.Time {
+	return fromNanos(a.nanos.Load())
+}
+
+func fromNanos(n int64) time.Time {
+	if n == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, n)
+}
+
+func newShard(u *usage, policy EvictionPolicy) *shard {
+	return &shard{
+		data:     make(map[string]common.Item),
+		accessed: make(map[string]*accessTime),
+		usage:    u,
+		policy:   policy,
+	}
+}
+
+// put stores the item and keeps size accounting, the access time and the
+// policy up to date. The caller must hold the write lock.
+func (sh *shard) put(key string, item common.Item) {
+	old, exists := sh.data[key]
+	delta := item.Value.Size()
+	if exists {
+		delta -= old.Value.Size()
+		sh.accessed[key].advance(item.Value.Meta.AccessedAt)
+	} else {
+		sh.usage.items.Add(1)
+		sh.accessed[key] = newAccessTime(item.Value.Meta.AccessedAt)
+	}
+	sh.data[key] = item
+	sh.bytes += delta
+	sh.usage.bytes.Add(int64(delta))
+
+	if sh.policy == nil {
+		return
+	}
+	if exists {
+		sh.policy.Accessed(key)
+	} else {
+		sh.policy.Added(key)
+	}
+}
+
+// remove deletes the key and keeps size accounting and the policy up to date.
+// The caller must hold the write lock.
+func (sh *shard) remove(key string) bool {
+	item, exists := sh.data[key]
+	if !exists {
+		return false
+	}
+	delete(sh.data, key)
+	delete(sh.accessed, key)
+	size := item.Value.Size()
+	sh.bytes -= size
+	sh.usage.items.Add(-1)
+	sh.usage.bytes.Add(-int64(size))
+	if sh.policy != nil {
+		sh.policy.Removed(key)
+	}
+	return true
+}
+
+// fnv32 is an inlined FNV-1a hash, used to pick the shard of a key without
+// allocating.
+func fnv32(key string) uint32 {
+	const (
+		offset = 2166136261
+		prime  = 16777619
+	)
+	h := uint32(offset)
+	for i := 0; i < len(key); i++ {
+		h ^= uint32(key[i])
+		h *= prime
+	}
+	return h
+}
@@ -0,0 +1,238 @@
+package store
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/johannessarpola/poor-cache-go/internal/common"
+	"github.com/johannessarpola/poor-cache-go/internal/logger"
+)
+
+// Snapshot file layout:
+//
+//	magic (6 bytes) | version (uint16) | gob stream of entries | crc32 (uint32)
+//
+// The checksum covers everything before it, so a truncated or corrupted file is
+// rejected as a whole.
+const (
+	snapshotMagic   = "PCSNAP"
+	snapshotVersion = uint16(1)
+)
+
+var (
+	ErrSnapshotCorrupt = errors.New("snapshot is truncated or corrupt")
+	ErrSnapshotVersion = errors.New("unsupported snapshot version")
+)
+
+type snapshotHeader struct {
+	Count int
+}
+
+type snapshotEntry struct {
+	Key  string
+	Item common.Item
+}
+
+// WithSnapshot loads the snapshot at path when the store is created, writes a
+// new one every interval and once more on Close. A zero interval only writes
+// on Close.
+func WithSnapshot(path string, interval time.Duration) Option {
+	return func(s *Store) {
+		s.snapshotPath = path
+		s.snapshotInterval = interval
+	}
+}
+
+// SaveSnapshot writes all non-expired items to path. The file is written next
+// to the target and renamed into place, so readers never see a partial file.
+func (s *Store) SaveSnapshot(path string) error {
+	entries := s.snapshotEntries()
+
+	var buf bytes.Buffer
+	buf.WriteString(snapshotMagic)
+	binary.Write(&buf, binary.BigEndian, snapshotVersion)
+
+	enc := gob.NewEncoder(&buf)
+	if err := enc.Encode(snapshotHeader{Count: len(entries)}); err != nil {
+		return err
+	}
+	for _, e := range entries {
+		if err := enc.Encode(e); err != nil {
+			return err
+		}
+	}
+	binary.Write(&buf, binary.BigEndian, crc32.ChecksumIEEE(buf.Bytes()))
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(buf.Bytes()); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// LoadSnapshot reads the snapshot at path into the store, skipping items that
+// expired while the process was down. Nothing is loaded unless the whole file
+// passes its checksum. It returns the number of items loaded.
+func (s *Store) LoadSnapshot(path string) (int, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+
+	entries, err := decodeSnapshot(b)
+	if err != nil {
+		return 0, err
+	}
+
+	now := time.Now()
+	n := 0
+	for _, e := range entries {
+		if e.Item.Expired(now) {
+			continue
+		}
+		s.observeVersion(e.Item.Value.Meta.Version)
+		sh := s.shardFor(e.Key)
+		sh.mu.Lock()
+		sh.put(e.Key, e.Item)
+		sh.mu.Unlock()
+		s.evict()
+		n++
+	}
+	return n, nil
+}
+
+func decodeSnapshot(b []byte) ([]snapshotEntry, error) {
+	headerLen := len(snapshotMagic) + 2
+	if len(b) < headerLen+4 || string(b[:len(snapshotMagic)]) != snapshotMagic {
+		return nil, ErrSnapshotCorrupt
+	}
+
+	body, sum := b[:len(b)-4], binary.BigEndian.Uint32(b[len(b)-4:])
+	if crc32.ChecksumIEEE(body) != sum {
+		return nil, ErrSnapshotCorrupt
+	}
+
+	version := binary.BigEndian.Uint16(b[len(snapshotMagic):headerLen])
+	if version != snapshotVersion {
+		return nil, fmt.Errorf("%w: %d", ErrSnapshotVersion, version)
+	}
+
+	dec := gob.NewDecoder(bytes.NewReader(body[headerLen:]))
+	var header snapshotHeader
+	if err := dec.Decode(&header); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrSnapshotCorrupt, err)
+	}
+
+	entries := make([]snapshotEntry, 0, header.Count)
+	for range header.Count {
+		var e snapshotEntry
+		if err := dec.Decode(&e); err != nil {
+			if err == io.EOF {
+				err = io.ErrUnexpectedEOF
+			}
+			return nil, fmt.Errorf("%w: %s", ErrSnapshotCorrupt, err)
+		}
+		entries = append(entries, e)
+	}
+	return entries, nil
+}
+
+// snapshotEntries copies the non-expired items of the whole store as of a
+// single point in time. Every shard is read locked for the copy, in index
+// order so that it cannot deadlock with writers locking several shards.
+func (s *Store) snapshotEntries() []snapshotEntry {
+	for _, sh := range s.shards {
+		sh.mu.RLock()
+	}
+	defer func() {
+		for _, sh := range s.shards {
+			sh.mu.RUnlock()
+		}
+	}()
+
+	var entries []snapshotEntry
+	now := time.Now()
+	for _, sh := range s.shards {
+		for key, item := range sh.data {
+			if !item.Expired(now) {
+				item.Value.Meta.AccessedAt = sh.accessed[key].load()
+				entries = append(entries, snapshotEntry{Key: key, Item: item})
+			}
+		}
+	}
+	return entries
+}
+
+// liveEntries copies the non-expired items of every shard, holding each shard's
+// lock only while copying it. Unlike a snapshot, the append log rewrite does
+// not need a single point in time: records appended while it runs are added
+// to the rewritten log.
+func (s *Store) liveEntries() []snapshotEntry {
+	var entries []snapshotEntry
+	now := time.Now()
+	for _, sh := range s.shards {
+		sh.mu.RLock()
+		for key, item := range sh.data {
+			if !item.Expired(now) {
+				item.Value.Meta.AccessedAt = sh.accessed[key].load()
+				entries = append(entries, snapshotEntry{Key: key, Item: item})
+			}
+		}
+		sh.mu.RUnlock()
+	}
+	return entries
+}
+
+func (s *Store) restoreSnapshot() {
+	n, err := s.LoadSnapshot(s.snapshotPath)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		logger.Infof("No snapshot at %s, starting empty", s.snapshotPath)
+	case err != nil:
+		logger.Errorf("Could not load snapshot %s: %s", s.snapshotPath, err)
+	default:
+		logger.Infof("Loaded %d items from snapshot %s", n, s.snapshotPath)
+	}
+}
+
+func (s *Store) writeSnapshot() {
+	if err := s.SaveSnapshot(s.snapshotPath); err != nil {
+		logger.Errorf("Could not write snapshot %s: %s", s.snapshotPath, err)
+	}
+}
+
+func (s *Store) snapshotPeriodically(quit chan struct{}) {
+	ticker := time.NewTicker(s.snapshotInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-quit:
+			fmt.Println("Snapshotter exiting...")
+			s.wg.Done()
+			return
+		case <-ticker.C:
+			s.writeSnapshot()
+		}
+	}
+}
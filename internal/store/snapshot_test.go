@@ -0,0 +1,59 @@
+package store
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestSnapshotRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache.snap")
+
+	s := New(WithSnapshot(path, 0))
+	s.Set("kept", TestStruct{Field1: "hello", Field2: 42}, time.Minute)
+	s.Set("expired", "soon gone", time.Millisecond)
+	time.Sleep(5 * time.Millisecond)
+	s.Close()
+
+	restored := New(WithSnapshot(path, 0))
+	defer restored.Close()
+
+	var v TestStruct
+	meta, err := restored.Get("kept", &v)
+	if err != nil || meta == nil {
+		t.Fatalf("expected kept to be restored, got %v", err)
+	}
+	if v.Field1 != "hello" || v.Field2 != 42 {
+		t.Fatalf("restored value does not match: %#v", v)
+	}
+	if restored.Has("expired") {
+		t.Fatalf("expected expired item to be skipped")
+	}
+}
+
+func TestSnapshotTruncated(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache.snap")
+
+	s := New()
+	defer s.Close()
+	s.Set("a", "value", time.Minute)
+	s.Set("b", "value", time.Minute)
+	if err := s.SaveSnapshot(path); err != nil {
+		t.Fatalf("SaveSnapshot failed: %v", err)
+	}
+
+	b, _ := os.ReadFile(path)
+	os.WriteFile(path, b[:len(b)-10], 0o644)
+
+	empty := New()
+	defer empty.Close()
+	n, err := empty.LoadSnapshot(path)
+	if !errors.Is(err, ErrSnapshotCorrupt) {
+		t.Fatalf("expected ErrSnapshotCorrupt, got %v", err)
+	}
+	if n != 0 || empty.Has("a") || empty.Has("b") {
+		t.Fatalf("expected nothing to be loaded from a truncated snapshot")
+	}
+}
@@ -1,22 +1,44 @@
 package store
 
 import (
+	"bytes"
+	"encoding/json"
 	"fmt"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/johannessarpola/poor-cache-go/internal/common"
+	"github.com/johannessarpola/poor-cache-go/internal/logger"
 	"github.com/johannessarpola/poor-cache-go/internal/rest"
+	"github.com/johannessarpola/poor-cache-go/internal/udp"
 )
 
 type Store struct {
-	mu              sync.RWMutex
-	data            map[string]common.Item
-	cleanupInterval time.Duration
-	cleanupQueue    chan string
-	mainQuit        chan struct{}
-	subQuits        []chan struct{} // this is to broadcast the quit signal to all subroutines
-	wg              *sync.WaitGroup
+	shards           []*shard
+	version          atomic.Uint64
+	maxBytes         int
+	maxItems         int
+	policy           EvictionPolicy
+	usage            usage
+	codec            Codec
+	compressMin      int
+	defaultTTL       time.Duration
+	snapshotPath     string
+	snapshotInterval time.Duration
+	log              *appendLog
+	logPath          string
+	logFsync         FsyncPolicy
+	logRewriteSize   int64
+	cleanupInterval  time.Duration
+	cleanupQueue     chan string
+	watchBuffer      int
+	watchers         watchers
+	loaders          []loaderRule
+	loads            flights
+	mainQuit         chan struct{}
+	subQuits         []chan struct{} // this is to broadcast the quit signal to all subroutines
+	wg               *sync.WaitGroup
 }
 
 type Option func(*Store)
@@ -27,17 +49,83 @@ func WithCleanupInterval(interval time.Duration) Option {
 	}
 }
 
-var _ rest.Store = (*Store)(nil)
+// WithShards sets the number of independently locked maps the keyspace is
+// split into. More shards mean less lock contention under parallel load.
+func WithShards(n int) Option {
+	return func(s *Store) {
+		if n > 0 {
+			s.shards = make([]*shard, n)
+		}
+	}
+}
+
+// WithMaxBytes bounds the total size of stored values. Sizes are measured on
+// the serialized value, so they reflect what the store actually keeps in memory.
+func WithMaxBytes(n int) Option {
+	return func(s *Store) {
+		s.maxBytes = n
+	}
+}
+
+// WithMaxItems bounds the number of keys kept in the store.
+func WithMaxItems(n int) Option {
+	return func(s *Store) {
+		s.maxItems = n
+	}
+}
+
+// WithEvictionPolicy sets the policy used to pick keys to drop once a limit
+// is reached. Bounded stores default to LRU.
+func WithEvictionPolicy(p EvictionPolicy) Option {
+	return func(s *Store) {
+		s.policy = p
+	}
+}
+
+// WithCodec sets the codec new values are encoded with. Items keep the codec
+// they were written with, so changing it does not affect existing data.
+func WithCodec(c Codec) Option {
+	return func(s *Store) {
+		s.codec = c
+	}
+}
+
+// WithCompressionThreshold stores values whose uncompressed encoding is smaller
+// than n bytes without compression, avoiding the compression header overhead.
+// It only applies when the store codec is a Compressor.
+func WithCompressionThreshold(n int) Option {
+	return func(s *Store) {
+		s.compressMin = n
+	}
+}
 
-const bufferSize = 32
+// WithDefaultTTL sets the ttl of writes that do not give one. A negative ttl
+// still stores items without expiration.
+func WithDefaultTTL(ttl time.Duration) Option {
+	return func(s *Store) {
+		s.defaultTTL = ttl
+	}
+}
+
+var (
+	_ rest.Store = (*Store)(nil)
+	_ udp.Store  = (*Store)(nil)
+)
+
+const (
+	bufferSize    = 32
+	defaultShards = 32
+)
 
 func New(opt ...Option) *Store {
 	wg := &sync.WaitGroup{}
 	s := &Store{
 		wg:              wg,
-		data:            make(map[string]common.Item),
+		shards:          make([]*shard, defaultShards),
+		codec:           DefaultCodec,
 		cleanupInterval: 1 * time.Minute,
 		cleanupQueue:    make(chan string, bufferSize),
+		watchBuffer:     defaultWatchBuffer,
 		mainQuit:        make(chan struct{}, 1),
 	}
 
@@ -45,6 +133,25 @@ func New(opt ...Option) *Store {
 		o(s)
 	}
 
+	if s.policy == nil && s.bounded() {
+		s.policy = NewLRU()
+	}
+	if s.policy != nil {
+		// Shards call the policy concurrently, each under its own lock.
+		s.policy = &syncPolicy{p: s.policy}
+	}
+
+	for i := range s.shards {
+		s.shards[i] = newShard(&s.usage, s.policy)
+	}
+
+	switch {
+	case s.logPath != "":
+		s.restoreLog()
+	case s.snapshotPath != "":
+		s.restoreSnapshot()
+	}
+
 	q1 := make(chan struct{}, 1)
 	s.subQuits = append(s.subQuits, q1)
 	go s.cleanupExpiredKeys(q1)
@@ -55,6 +162,13 @@ func New(opt ...Option) *Store {
 	go s.takekOutTheTrash(q2)
 	wg.Add(1)
 
+	if s.snapshotPath != "" && s.snapshotInterval > 0 {
+		q3 := make(chan struct{}, 1)
+		s.subQuits = append(s.subQuits, q3)
+		go s.snapshotPeriodically(q3)
+		wg.Add(1)
+	}
+
 	go s.broadcastQuits()
 	wg.Add(1)
 	return s
@@ -69,55 +183,432 @@ func (s *Store) broadcastQuits() {
 	s.wg.Done()
 }
 
-func (s *Store) Set(key string, value any, ttl time.Duration) error {
-	s.mu.Lock()
-	defer s.mu.Unlock()
+func (s *Store) shardFor(key string) *shard {
+	return s.shards[fnv32(key)%uint32(len(s.shards))]
+}
 
-	v, err := Serialize(value)
+// Set encodes value with the store codec and stores it under key. A zero ttl
+// uses the default ttl of the store, if any; otherwise, and for negative ttls,
+// the item never expires.
+func (s *Store) Set(key string, value any, ttl time.Duration) error {
+	// Serialization is the expensive part, keep it out of the critical section.
+	v, codec, err := s.encode(value)
 	if err != nil {
 		return err
 	}
+	_, err = s.write(key, v, common.Meta{Codec: codec}, ttl, nil)
+	return err
+}
+
+// SetBytes stores data exactly as given together with its content type, so
+// non-JSON payloads can be cached and read back byte for byte. Data is kept
+// compressed when the store codec compresses. The ttl works like in Set. It
+// returns the metadata of the written item.
+func (s *Store) SetBytes(key string, data []byte, contentType string, ttl time.Duration) (*common.Meta, error) {
+	return s.setBytes(key, data, contentType, ttl, nil)
+}
 
-	meta := common.Meta{
-		CreatedAt:  time.Now(),
-		ModifiedAt: time.Now(),
+// CompareAndSet is SetBytes that only succeeds while key holds the given
+// version. It fails with common.ErrVersionMismatch otherwise, including when
+// the key does not exist.
+func (s *Store) CompareAndSet(key string, data []byte, contentType string, ttl time.Duration, version uint64) (*common.Meta, error) {
+	return s.setBytes(key, data, contentType, ttl, func(current *common.Item) error {
+		if current == nil || current.Value.Meta.Version != version {
+			return common.ErrVersionMismatch
+		}
+		return nil
+	})
+}
+
+// SetNX is SetBytes that only succeeds when key is missing or expired. It fails
+// with common.ErrExists otherwise.
+func (s *Store) SetNX(key string, data []byte, contentType string, ttl time.Duration) (*common.Meta, error) {
+	return s.setBytes(key, data, contentType, ttl, func(current *common.Item) error {
+		if current != nil {
+			return common.ErrExists
+		}
+		return nil
+	})
+}
+
+// SetXX is SetBytes that only succeeds when key holds a live item. It fails
+// with common.ErrNotFound otherwise.
+func (s *Store) SetXX(key string, data []byte, contentType string, ttl time.Duration) (*common.Meta, error) {
+	return s.setBytes(key, data, contentType, ttl, func(current *common.Item) error {
+		if current == nil {
+			return common.ErrNotFound
+		}
+		return nil
+	})
+}
+
+func (s *Store) setBytes(key string, data []byte, contentType string, ttl time.Duration, cond condition) (*common.Meta, error) {
+	if contentType == "" {
+		contentType = common.ContentTypeBinary
+	}
+	data, codec, err := s.encodeBytes(data)
+	if err != nil {
+		return nil, err
+	}
+	return s.write(key, data, common.Meta{Codec: codec, ContentType: contentType}, ttl, cond)
+}
+
+// condition checks the state of a key right before it is written, under the
+// shard lock. current is nil when the key is missing or expired.
+type condition func(current *common.Item) error
+
+func (s *Store) write(key string, data []byte, meta common.Meta, ttl time.Duration, cond condition) (*common.Meta, error) {
+	if s.maxBytes > 0 && len(data) > s.maxBytes {
+		return nil, common.ErrTooLarge
 	}
 
-	expiration := time.Now().Add(ttl)
-	s.data[key] = common.Item{Value: common.Value{Meta: meta, Data: v}, Expiration: expiration}
+	now := time.Now()
+	item := newItem(data, meta, now, s.ttlFor(ttl))
+
+	sh := s.shardFor(key)
+	defer s.evict()
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+	var current *common.Item
+	if old, exists := sh.data[key]; exists && !old.Expired(now) {
+		current = &old
+		// Overwrites keep the creation time of the value they replace.
+		item.Value.Meta.CreatedAt = old.Value.Meta.CreatedAt
+	}
+	if cond != nil {
+		if err := cond(current); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.commit(sh, key, &item); err != nil {
+		return nil, err
+	}
+	written := item.Value.Meta
+	return &written, nil
+}
+
+func newItem(data []byte, meta common.Meta, now time.Time, ttl time.Duration) common.Item {
+	meta.CreatedAt = now
+	meta.ModifiedAt = now
+	meta.AccessedAt = now
+	item := common.Item{Value: common.Value{Meta: meta, Data: data}}
+	setExpiration(&item, now, ttl)
+	return item
+}
+
+// commit gives item the next version, logs it and stores it. The caller must
+// hold the shard lock, and call evict once it is released.
+func (s *Store) commit(sh *shard, key string, item *common.Item) error {
+	item.Value.Meta.Version = s.version.Add(1)
+	if err := s.logSet(key, *item); err != nil {
+		return err
+	}
+	sh.put(key, *item)
+	s.publishSet(key, item.Value.Meta.Version)
 	return nil
 }
 
+func (s *Store) overLimit() bool {
+	return (s.maxItems > 0 && s.usage.items.Load() > int64(s.maxItems)) ||
+		(s.maxBytes > 0 && s.usage.bytes.Load() > int64(s.maxBytes))
+}
+
+// evict drops keys chosen by the policy until the store is within its limits,
+// logging and publishing their removal. Victims may live in any shard, so the
+// caller must not hold a shard lock.
+func (s *Store) evict() {
+	for s.overLimit() {
+		key, ok := s.policy.Victim()
+		if !ok {
+			return
+		}
+		sh := s.shardFor(key)
+		sh.mu.Lock()
+		if _, exists := sh.data[key]; exists {
+			s.logDelete(key)
+			sh.remove(key)
+			s.publishDelete(common.ReasonEvicted, key)
+		} else {
+			// The policy is out of sync with the data, forget the key.
+			s.policy.Removed(key)
+		}
+		sh.mu.Unlock()
+	}
+}
+
 func (s *Store) Get(key string, dest any) (*common.Meta, error) {
-	s.mu.RLock()
-	defer s.mu.RUnlock()
-	item, exists := s.data[key]
+	item, exists := s.lookup(key)
 	if !exists {
 		return nil, nil
 	}
 
-	if time.Now().After(item.Expiration) {
-		s.cleanupQueue <- key // This should not block since the channel is buffered.
-		return nil, nil
+	// Stored data is never mutated in place, so it is safe to decode unlocked.
+	if item.Value.Kind != "" {
+		return nil, common.ErrWrongType
 	}
-
 	meta := item.Value.Meta
-	err := Deserialize(item.Value.Data, dest)
+	codec, err := CodecByName(meta.Codec)
+	if err != nil {
+		return nil, err
+	}
+	err = codec.Decode(item.Value.Data, dest)
 	return &meta, err
 }
 
+// GetBytes returns the stored bytes of key and its metadata. Items written
+// through a JSON codec are returned as JSON. Missing keys covered by a loader
+// are loaded first, see WithLoader. Both are nil when the key is missing.
+func (s *Store) GetBytes(key string) ([]byte, *common.Meta, error) {
+	item, exists := s.lookup(key)
+	if !exists {
+		return s.load(key)
+	}
+	return readBytes(item)
+}
+
+// readBytes decodes the data of a plain item.
+func readBytes(item common.Item) ([]byte, *common.Meta, error) {
+	if item.Value.Kind != "" {
+		return nil, nil, common.ErrWrongType
+	}
+
+	meta := item.Value.Meta
+	codec, err := CodecByName(meta.Codec)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var data json.RawMessage
+	if err := codec.Decode(item.Value.Data, &data); err != nil {
+		return nil, nil, err
+	}
+	if meta.ContentType == "" {
+		meta.ContentType = common.ContentTypeJSON
+	}
+	return data, &meta, nil
+}
+
+// lookup returns the live item stored under key, queueing it for cleanup if it
+// has expired. Reads record the access time; the returned item still carries
+// the previous one.
+func (s *Store) lookup(key string) (common.Item, bool) {
+	sh := s.shardFor(key)
+	now := time.Now()
+	// Eviction policies track reads, so a bounded store needs the write lock.
+	// Otherwise the access time is recorded atomically under the read lock.
+	if sh.policy != nil {
+		sh.mu.Lock()
+	} else {
+		sh.mu.RLock()
+	}
+	item, exists := sh.data[key]
+	live := exists && !item.Expired(now)
+	if live {
+		item.Value.Meta.AccessedAt = sh.accessed[key].swap(now)
+		if sh.policy != nil {
+			sh.policy.Accessed(key)
+		}
+	}
+	if sh.policy != nil {
+		sh.mu.Unlock()
+	} else {
+		sh.mu.RUnlock()
+	}
+
+	if exists && !live {
+		select {
+		case s.cleanupQueue <- key:
+		default: // the periodic cleanup will pick it up
+		}
+	}
+	return item, live
+}
+
+// observeVersion makes sure versions handed out later are above one loaded
+// from disk.
+func (s *Store) observeVersion(v uint64) {
+	for {
+		current := s.version.Load()
+		if v <= current || s.version.CompareAndSwap(current, v) {
+			return
+		}
+	}
+}
+
 func (s *Store) Delete(key string) error {
-	s.mu.Lock()
-	defer s.mu.Unlock()
-	delete(s.data, key)
+	sh := s.shardFor(key)
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+	if _, exists := sh.data[key]; !exists {
+		return nil
+	}
+	if err := s.logDelete(key); err != nil {
+		return err
+	}
+	sh.remove(key)
+	s.publishDelete("", key)
 	return nil
 }
 
+// Has reports whether key holds a live item. Expired items that have not been
+// cleaned up yet are reported as missing, matching Get.
 func (s *Store) Has(key string) bool {
-	s.mu.RLock()
-	defer s.mu.RUnlock()
-	_, exists := s.data[key]
-	return exists
+	sh := s.shardFor(key)
+	sh.mu.RLock()
+	defer sh.mu.RUnlock()
+	item, exists := sh.data[key]
+	return exists && !item.Expired(time.Now())
+}
+
+// Expire sets a new time to live on an existing key without rewriting its
+// value.
+func (s *Store) Expire(key string, ttl time.Duration) error {
+	if ttl <= 0 {
+		return common.ErrInvalidTTL
+	}
+	return s.update(key, func(item *common.Item) error {
+		setExpiration(item, time.Now(), ttl)
+		return nil
+	})
+}
+
+// Persist removes the expiration of an existing key.
+func (s *Store) Persist(key string) error {
+	return s.update(key, func(item *common.Item) error {
+		setExpiration(item, time.Now(), 0)
+		return nil
+	})
+}
+
+// Touch renews the lease of an existing key, pushing its expiration out by the
+// ttl it was last given, and records an access. Keys without expiration only
+// have the access recorded.
+func (s *Store) Touch(key string) error {
+	return s.update(key, func(item *common.Item) error {
+		now := time.Now()
+		setExpiration(item, now, item.Lease)
+		item.Value.Meta.AccessedAt = now
+		return nil
+	})
+}
+
+// update applies fn to the live item stored under key while holding its shard
+// lock, then logs and stores the result. fn must not modify the item's data in
+// place, readers may still hold it.
+func (s *Store) update(key string, fn func(item *common.Item) error) error {
+	sh := s.shardFor(key)
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+	item, exists := sh.data[key]
+	if !exists || item.Expired(time.Now()) {
+		return common.ErrNotFound
+	}
+	if err := fn(&item); err != nil {
+		return err
+	}
+	if err := s.logSet(key, item); err != nil {
+		return err
+	}
+	sh.put(key, item)
+	return nil
+}
+
+// ttlFor returns the ttl a write should use, filling in the default.
+func (s *Store) ttlFor(ttl time.Duration) time.Duration {
+	if ttl == 0 {
+		return s.defaultTTL
+	}
+	return ttl
+}
+
+func setExpiration(item *common.Item, now time.Time, ttl time.Duration) {
+	if ttl <= 0 {
+		item.Expiration = time.Time{}
+		item.Lease = 0
+		return
+	}
+	item.Expiration = now.Add(ttl)
+	item.Lease = ttl
+}
+
+// TTL returns the remaining time to live of key, or common.NoExpiry for keys
+// that never expire. ok is false when the key is missing or expired.
+func (s *Store) TTL(key string) (ttl time.Duration, ok bool) {
+	sh := s.shardFor(key)
+	sh.mu.RLock()
+	defer sh.mu.RUnlock()
+	item, exists := sh.data[key]
+	now := time.Now()
+	if !exists || item.Expired(now) {
+		return 0, false
+	}
+	return item.TTL(now), true
+}
+
+// encode serializes value with the store codec and returns the name of the
+// codec that was actually used.
+func (s *Store) encode(value any) ([]byte, string, error) {
+	c, ok := s.codec.(Compressor)
+	if !ok || s.compressMin <= 0 {
+		data, err := s.codec.Encode(value)
+		return data, s.codec.Name(), err
+	}
+
+	base := c.Uncompressed()
+	data, err := base.Encode(value)
+	if err != nil {
+		return nil, "", err
+	}
+	if len(data) < s.compressMin {
+		return data, base.Name(), nil
+	}
+	data, err = c.Compress(data)
+	return data, c.Name(), err
+}
+
+// encodeBytes copies data, compressing it when the store codec compresses and
+// data is above the compression threshold. Decoding gives back the exact bytes.
+func (s *Store) encodeBytes(data []byte) ([]byte, string, error) {
+	c, ok := rawCompressors[s.codec]
+	if !ok || len(data) < s.compressMin {
+		return bytes.Clone(data), Raw.Name(), nil
+	}
+	data, err := c.Compress(data)
+	return data, c.Name(), err
+}
+
+func (s *Store) bounded() bool {
+	return s.maxBytes > 0 || s.maxItems > 0
+}
+
+// deleteExpired removes the key only if it is still expired, so a value that
+// was rewritten after being queued for cleanup survives.
+func (s *Store) deleteExpired(key string) {
+	sh := s.shardFor(key)
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+	if item, exists := sh.data[key]; exists && item.Expired(time.Now()) {
+		sh.remove(key)
+		s.publishDelete(common.ReasonExpired, key)
+	}
+}
+
+// expiredKeys collects the expired keys of every shard, holding each shard's
+// lock only while scanning it.
+func (s *Store) expiredKeys() []string {
+	var keys []string
+	now := time.Now()
+	for _, sh := range s.shards {
+		sh.mu.RLock()
+		for key, item := range sh.data {
+			if item.Expired(now) {
+				keys = append(keys, key)
+			}
+		}
+		sh.mu.RUnlock()
+	}
+	return keys
 }
 
 func (s *Store) takekOutTheTrash(quit chan struct{}) {
@@ -131,7 +622,7 @@ func (s *Store) takekOutTheTrash(quit chan struct{}) {
 			if !ok {
 				return
 			}
-			s.Delete(v)
+			s.deleteExpired(v)
 		}
 	}
 }
@@ -147,12 +638,10 @@ func (s *Store) cleanupExpiredKeys(quit chan struct{}) {
 			s.wg.Done()
 			return
 		case <-ticker:
-			for key, data := range s.data {
-				if time.Now().After(data.Expiration) {
-					select {
-					case <-timeouter:
-					case s.cleanupQueue <- key:
-					}
+			for _, key := range s.expiredKeys() {
+				select {
+				case <-timeouter:
+				case s.cleanupQueue <- key:
 				}
 			}
 		}
@@ -163,4 +652,13 @@ func (s *Store) Close() {
 	s.mainQuit <- struct{}{}
 	close(s.mainQuit)
 	s.wg.Wait()
+
+	if s.snapshotPath != "" {
+		s.writeSnapshot()
+	}
+	if s.log != nil {
+		if err := s.log.close(); err != nil {
+			logger.Errorf("Could not close append log %s: %s", s.logPath, err)
+		}
+	}
 }
@@ -0,0 +1,276 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/johannessarpola/poor-cache-go/internal/common"
+)
+
+func TestStoreConcurrentAccess(t *testing.T) {
+	s := New(WithShards(4))
+	defer s.Close()
+
+	var wg sync.WaitGroup
+	for w := 0; w < 8; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < 100; i++ {
+				key := fmt.Sprintf("key-%d-%d", w, i)
+				if err := s.Set(key, i, time.Minute); err != nil {
+					t.Errorf("Set failed: %v", err)
+					return
+				}
+				var v int
+				if _, err := s.Get(key, &v); err != nil || v != i {
+					t.Errorf("Get returned %d, %v for %s", v, err, key)
+					return
+				}
+				if i%2 == 0 {
+					s.Delete(key)
+				}
+			}
+		}(w)
+	}
+	wg.Wait()
+
+	for w := 0; w < 8; w++ {
+		for i := 0; i < 100; i++ {
+			key := fmt.Sprintf("key-%d-%d", w, i)
+			if s.Has(key) != (i%2 != 0) {
+				t.Fatalf("unexpected presence of %s", key)
+			}
+		}
+	}
+}
+
+func TestStoreLimitsAreStoreWide(t *testing.T) {
+	s := New(WithMaxItems(2))
+	defer s.Close()
+	for i := 0; i < 100; i++ {
+		s.Set(fmt.Sprintf("key-%d", i), i, time.Minute)
+	}
+	kept := 0
+	for i := 0; i < 100; i++ {
+		if s.Has(fmt.Sprintf("key-%d", i)) {
+			kept++
+		}
+	}
+	if kept != 2 {
+		t.Fatalf("expected 2 items to be kept, got %d", kept)
+	}
+
+	s = New(WithMaxBytes(1000))
+	defer s.Close()
+	if err := s.Set("k", strings.Repeat("x", 100), time.Minute); err != nil || !s.Has("k") {
+		t.Fatalf("expected a value within the store limit to be kept, got %v", err)
+	}
+}
+
+func TestStoreBytesRoundTrip(t *testing.T) {
+	s := New()
+	defer s.Close()
+
+	payload := []byte(`{"b": 12345678901234567890, "a": 1}`)
+	s.SetBytes("json", payload, "application/json", time.Minute)
+	s.SetBytes("binary", []byte{0x00, 0xff}, "", time.Minute)
+	s.Set("legacy", map[string]int{"a": 1}, time.Minute)
+
+	data, meta, err := s.GetBytes("json")
+	if err != nil || string(data) != string(payload) || meta.ContentType != "application/json" {
+		t.Fatalf("json bytes did not round trip: %s %v", data, err)
+	}
+
+	data, meta, _ = s.GetBytes("binary")
+	if string(data) != "\x00\xff" || meta.ContentType != "application/octet-stream" {
+		t.Fatalf("binary bytes did not round trip: %v %s", data, meta.ContentType)
+	}
+
+	data, meta, _ = s.GetBytes("legacy")
+	if string(data) != `{"a":1}` || meta.ContentType != "application/json" {
+		t.Fatalf("codec encoded value was not returned as json: %s", data)
+	}
+
+	if data, meta, _ := s.GetBytes("missing"); data != nil || meta != nil {
+		t.Fatalf("expected nothing for a missing key")
+	}
+}
+
+func TestStoreHasRespectsExpiration(t *testing.T) {
+	s := New()
+	defer s.Close()
+
+	s.Set("short", "value", 10*time.Millisecond)
+	if !s.Has("short") {
+		t.Fatalf("expected short to exist before expiring")
+	}
+
+	time.Sleep(20 * time.Millisecond)
+	if s.Has("short") {
+		t.Fatalf("expected Has to report an expired key as missing")
+	}
+	if _, ok := s.TTL("short"); ok {
+		t.Fatalf("expected TTL to report an expired key as missing")
+	}
+}
+
+func TestStoreTTL(t *testing.T) {
+	s := New()
+	defer s.Close()
+
+	s.Set("key", "value", time.Minute)
+	ttl, ok := s.TTL("key")
+	if !ok || ttl <= 0 || ttl > time.Minute {
+		t.Fatalf("unexpected ttl %v, %v", ttl, ok)
+	}
+
+	if _, ok := s.TTL("missing"); ok {
+		t.Fatalf("expected missing key to have no ttl")
+	}
+}
+
+func TestStoreNoExpiry(t *testing.T) {
+	s := New()
+	defer s.Close()
+
+	s.Set("forever", "value", 0)
+	if ttl, ok := s.TTL("forever"); !ok || ttl != common.NoExpiry {
+		t.Fatalf("expected no expiry, got %v, %v", ttl, ok)
+	}
+}
+
+func TestStoreExpirePersistTouch(t *testing.T) {
+	s := New()
+	defer s.Close()
+
+	if err := s.Expire("missing", time.Minute); !errors.Is(err, common.ErrNotFound) {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+	if err := s.Expire("missing", 0); !errors.Is(err, common.ErrInvalidTTL) {
+		t.Fatalf("expected ErrInvalidTTL, got %v", err)
+	}
+
+	s.Set("key", "value", 0)
+	s.Expire("key", 50*time.Millisecond)
+	if ttl, _ := s.TTL("key"); ttl == common.NoExpiry || ttl > 50*time.Millisecond {
+		t.Fatalf("expected key to expire after Expire, got %v", ttl)
+	}
+
+	time.Sleep(30 * time.Millisecond)
+	s.Touch("key")
+	if ttl, _ := s.TTL("key"); ttl < 40*time.Millisecond {
+		t.Fatalf("expected Touch to renew the lease, got %v", ttl)
+	}
+
+	s.Persist("key")
+	time.Sleep(60 * time.Millisecond)
+	if ttl, ok := s.TTL("key"); !ok || ttl != common.NoExpiry {
+		t.Fatalf("expected key to be persisted, got %v, %v", ttl, ok)
+	}
+
+	var v string
+	if _, err := s.Get("key", &v); err != nil || v != "value" {
+		t.Fatalf("expected value to survive ttl changes, got %q", v)
+	}
+}
+
+func TestStoreMetaOnOverwrite(t *testing.T) {
+	s := New()
+	defer s.Close()
+
+	s.Set("key", "first", time.Minute)
+	first, _ := s.Get("key", new(string))
+	time.Sleep(2 * time.Millisecond)
+
+	s.Set("key", "second", time.Minute)
+	second, _ := s.Get("key", new(string))
+
+	if !second.CreatedAt.Equal(first.CreatedAt) {
+		t.Fatalf("expected CreatedAt to be kept on overwrite")
+	}
+	if !second.ModifiedAt.After(first.ModifiedAt) {
+		t.Fatalf("expected ModifiedAt to move on overwrite")
+	}
+	if second.Version <= first.Version {
+		t.Fatalf("expected version to increase, %d <= %d", second.Version, first.Version)
+	}
+
+	third, _ := s.Get("key", new(string))
+	if !third.AccessedAt.After(second.ModifiedAt) {
+		t.Fatalf("expected reads to record the access time")
+	}
+	s.Expire("key", time.Minute)
+	fourth, _ := s.Get("key", new(string))
+	if !fourth.AccessedAt.After(third.AccessedAt) {
+		t.Fatalf("expected updates to keep the access time of the last read")
+	}
+
+	s.Delete("key")
+	s.Set("key", "recreated", time.Minute)
+	recreated, _ := s.Get("key", new(string))
+	if recreated.Version <= second.Version || !recreated.CreatedAt.After(first.CreatedAt) {
+		t.Fatalf("expected a recreated key to start a new life with a higher version")
+	}
+}
+
+func TestStoreCompareAndSet(t *testing.T) {
+	s := New()
+	defer s.Close()
+
+	if _, err := s.CompareAndSet("key", []byte("v"), "", 0, 1); !errors.Is(err, common.ErrVersionMismatch) {
+		t.Fatalf("expected ErrVersionMismatch for a missing key, got %v", err)
+	}
+
+	meta, _ := s.SetBytes("key", []byte("first"), "text/plain", 0)
+
+	updated, err := s.CompareAndSet("key", []byte("second"), "text/plain", 0, meta.Version)
+	if err != nil {
+		t.Fatalf("CompareAndSet failed: %v", err)
+	}
+	if updated.Version <= meta.Version {
+		t.Fatalf("expected a new version after CompareAndSet")
+	}
+
+	if _, err := s.CompareAndSet("key", []byte("stale"), "text/plain", 0, meta.Version); !errors.Is(err, common.ErrVersionMismatch) {
+		t.Fatalf("expected ErrVersionMismatch for a stale version, got %v", err)
+	}
+
+	data, _, _ := s.GetBytes("key")
+	if string(data) != "second" {
+		t.Fatalf("expected second, got %s", data)
+	}
+}
+
+func TestStoreSetNXAndSetXX(t *testing.T) {
+	s := New()
+	defer s.Close()
+
+	if _, err := s.SetXX("key", []byte("v"), "", 0); !errors.Is(err, common.ErrNotFound) {
+		t.Fatalf("expected ErrNotFound from SetXX on a missing key, got %v", err)
+	}
+	if _, err := s.SetNX("key", []byte("first"), "", 0); err != nil {
+		t.Fatalf("SetNX failed on a missing key: %v", err)
+	}
+	if _, err := s.SetNX("key", []byte("second"), "", 0); !errors.Is(err, common.ErrExists) {
+		t.Fatalf("expected ErrExists from SetNX on an existing key, got %v", err)
+	}
+	if _, err := s.SetXX("key", []byte("third"), "", 0); err != nil {
+		t.Fatalf("SetXX failed on an existing key: %v", err)
+	}
+
+	s.SetBytes("expiring", []byte("old"), "", 10*time.Millisecond)
+	time.Sleep(20 * time.Millisecond)
+	if _, err := s.SetNX("expiring", []byte("new"), "", 0); err != nil {
+		t.Fatalf("expected SetNX to succeed on an expired key, got %v", err)
+	}
+
+	data, _, _ := s.GetBytes("key")
+	if string(data) != "third" {
+		t.Fatalf("expected third, got %s", data)
+	}
+}
@@ -0,0 +1,114 @@
+package store
+
+import (
+	"slices"
+	"time"
+
+	"github.com/johannessarpola/poor-cache-go/internal/common"
+)
+
+// Tx applies ops atomically. The shards of all keys involved are locked for
+// the whole transaction, in index order so concurrent transactions cannot
+// deadlock, and readers see either none or all of the changes. Operations see
+// the effects of the ones before them. When an operation fails nothing is
+// applied and the error is a *common.TxError naming the failing operation.
+// Results are in the order of ops; deletes have a nil Meta.
+func (s *Store) Tx(ops []common.TxOp) ([]common.Result, error) {
+	for i, op := range ops {
+		switch op.Op {
+		case common.OpSet, common.OpCAS, common.OpDelete:
+		default:
+			return nil, &common.TxError{Index: i, Err: common.ErrUnknownOp}
+		}
+	}
+
+	defer s.evict()
+	locked := s.lockShards(ops)
+	defer func() {
+		for _, i := range locked {
+			s.shards[i].mu.Unlock()
+		}
+	}()
+
+	// pending holds the state of every key touched so far, nil once deleted.
+	now := time.Now()
+	pending := make(map[string]*common.Item)
+	current := func(key string) *common.Item {
+		if item, ok := pending[key]; ok {
+			return item
+		}
+		if item, ok := s.shardFor(key).data[key]; ok && !item.Expired(now) {
+			return &item
+		}
+		return nil
+	}
+
+	results := make([]common.Result, len(ops))
+	records := make([]logRecord, 0, len(ops))
+	for i, op := range ops {
+		results[i].Key = op.Key
+		cur := current(op.Key)
+		if op.Op == common.OpDelete {
+			pending[op.Key] = nil
+			records = append(records, logRecord{Op: opDelete, Key: op.Key})
+			continue
+		}
+		if op.Op == common.OpCAS && (cur == nil || cur.Value.Meta.Version != op.Version) {
+			return nil, &common.TxError{Index: i, Err: common.ErrVersionMismatch}
+		}
+
+		contentType := op.ContentType
+		if contentType == "" {
+			contentType = common.ContentTypeBinary
+		}
+		data, codec, err := s.encodeBytes(op.Data)
+		if err != nil {
+			return nil, &common.TxError{Index: i, Err: err}
+		}
+		if s.maxBytes > 0 && len(data) > s.maxBytes {
+			return nil, &common.TxError{Index: i, Err: common.ErrTooLarge}
+		}
+		meta := common.Meta{Codec: codec, ContentType: contentType}
+		item := newItem(data, meta, now, s.ttlFor(op.TTL))
+		if cur != nil {
+			item.Value.Meta.CreatedAt = cur.Value.Meta.CreatedAt
+		}
+		item.Value.Meta.Version = s.version.Add(1)
+		pending[op.Key] = &item
+		records = append(records, logRecord{Op: opSet, Key: op.Key, Item: &item})
+
+		written := item.Value.Meta
+		results[i].Meta = &written
+	}
+
+	if err := s.logTx(records); err != nil {
+		return nil, err
+	}
+	for _, rec := range records {
+		sh := s.shardFor(rec.Key)
+		if rec.Op == opDelete {
+			if sh.remove(rec.Key) {
+				s.publishDelete("", rec.Key)
+			}
+		} else {
+			sh.put(rec.Key, *rec.Item)
+			s.publishSet(rec.Key, rec.Item.Value.Meta.Version)
+		}
+	}
+	return results, nil
+}
+
+// lockShards write locks the shards of all keys in ops in ascending index
+// order and returns the locked indexes.
+func (s *Store) lockShards(ops []common.TxOp) []int {
+	idx := make([]int, 0, len(ops))
+	for _, op := range ops {
+		idx = append(idx, int(fnv32(op.Key)%uint32(len(s.shards))))
+	}
+	slices.Sort(idx)
+	idx = slices.Compact(idx)
+	for _, i := range idx {
+		s.shards[i].mu.Lock()
+	}
+	return idx
+}
@@ -0,0 +1,91 @@
+package store
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/johannessarpola/poor-cache-go/internal/common"
+)
+
+func TestTxAppliesAllOrNothing(t *testing.T) {
+	s := New()
+	defer s.Close()
+
+	user, _ := s.SetBytes("user:1", []byte(`{"name": "old"}`), "application/json", 0)
+	s.SetBytes("index:old", []byte(`"user:1"`), "application/json", 0)
+
+	_, err := s.Tx([]common.TxOp{
+		{Op: common.OpSet, Key: "index:new", Data: []byte(`"user:1"`)},
+		{Op: common.OpCAS, Key: "user:1", Data: []byte(`{"name": "new"}`), Version: user.Version + 100},
+	})
+	var txErr *common.TxError
+	if !errors.As(err, &txErr) || txErr.Index != 1 || !errors.Is(err, common.ErrVersionMismatch) {
+		t.Fatalf("expected the cas to fail the transaction, got %v", err)
+	}
+	if s.Has("index:new") {
+		t.Fatalf("expected nothing to be applied after a failed precondition")
+	}
+
+	results, err := s.Tx([]common.TxOp{
+		{Op: common.OpCAS, Key: "user:1", Data: []byte(`{"name": "new"}`), ContentType: "application/json", Version: user.Version},
+		{Op: common.OpDelete, Key: "index:old"},
+		{Op: common.OpSet, Key: "index:new", Data: []byte(`"user:1"`), TTL: time.Minute},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if results[0].Meta == nil || results[0].Meta.CreatedAt != user.CreatedAt || results[1].Meta != nil {
+		t.Fatalf("unexpected results: %#v", results)
+	}
+	if s.Has("index:old") || !s.Has("index:new") {
+		t.Fatalf("expected the index to move")
+	}
+	if data, _, _ := s.GetBytes("user:1"); string(data) != `{"name": "new"}` {
+		t.Fatalf("expected user to be updated, got %s", data)
+	}
+}
+
+func TestTxSeesEarlierOps(t *testing.T) {
+	s := New()
+	defer s.Close()
+
+	results, err := s.Tx([]common.TxOp{
+		{Op: common.OpSet, Key: "a", Data: []byte("1")},
+		{Op: common.OpDelete, Key: "a"},
+	})
+	if err != nil || s.Has("a") {
+		t.Fatalf("expected a to end up deleted: %v", err)
+	}
+
+	_, err = s.Tx([]common.TxOp{
+		{Op: common.OpSet, Key: "b", Data: []byte("1")},
+		{Op: common.OpCAS, Key: "b", Data: []byte("2"), Version: results[0].Meta.Version},
+	})
+	if !errors.Is(err, common.ErrVersionMismatch) {
+		t.Fatalf("expected cas to check the version written earlier in the transaction, got %v", err)
+	}
+
+	if _, err := s.Tx([]common.TxOp{{Op: "incr", Key: "c"}}); !errors.Is(err, common.ErrUnknownOp) {
+		t.Fatalf("expected unknown operation error, got %v", err)
+	}
+}
+
+func TestTxReplaysFromAppendLog(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache.aof")
+
+	s := New(WithAppendLog(path, FsyncAlways, 0))
+	s.SetBytes("old", []byte("x"), "", 0)
+	s.Tx([]common.TxOp{
+		{Op: common.OpDelete, Key: "old"},
+		{Op: common.OpSet, Key: "new", Data: []byte("y")},
+	})
+	s.Close()
+
+	restored := New(WithAppendLog(path, FsyncAlways, 0))
+	defer restored.Close()
+	if restored.Has("old") || !restored.Has("new") {
+		t.Fatalf("expected the transaction to be replayed")
+	}
+}
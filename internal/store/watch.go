@@ -0,0 +1,111 @@
+package store
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/johannessarpola/poor-cache-go/internal/common"
+)
+
+const defaultWatchBuffer = 256
+
+// WithWatchBuffer sets how many events a watcher may fall behind before
+// events are dropped for it.
+func WithWatchBuffer(n int) Option {
+	return func(s *Store) {
+		if n > 1 {
+			s.watchBuffer = n
+		}
+	}
+}
+
+// watchers fans keyspace events out to subscribers. Events are published
+// while the shard of the key is locked, so events for a key arrive in the
+// order of its changes.
+type watchers struct {
+	mu    sync.RWMutex
+	subs  map[*watcher]struct{}
+	count atomic.Int32 // len(subs), read without the lock on every write
+}
+
+type watcher struct {
+	prefix string
+	mu     sync.Mutex
+	events chan common.Event
+	behind bool // an overflow event was queued and nothing since
+}
+
+// Watch subscribes to changes of keys starting with prefix, every key for an
+// empty prefix. Events arrive on the returned channel until ctx is done, then
+// it is closed.
+//
+// Writers never wait for watchers. A watcher that falls more than the watch
+// buffer behind loses events, and gets an overflow event in their place so it
+// knows to resync. The last slot of the buffer is kept for that event.
+// Expirations are reported when the store cleans up the expired key, which
+// may be well after the expiration passed.
+func (s *Store) Watch(ctx context.Context, prefix string) <-chan common.Event {
+	w := &watcher{prefix: prefix, events: make(chan common.Event, s.watchBuffer)}
+
+	s.watchers.mu.Lock()
+	if s.watchers.subs == nil {
+		s.watchers.subs = make(map[*watcher]struct{})
+	}
+	s.watchers.subs[w] = struct{}{}
+	s.watchers.count.Add(1)
+	s.watchers.mu.Unlock()
+
+	go func() {
+		<-ctx.Done()
+		s.watchers.mu.Lock()
+		delete(s.watchers.subs, w)
+		s.watchers.count.Add(-1)
+		close(w.events)
+		s.watchers.mu.Unlock()
+	}()
+	return w.events
+}
+
+// publish sends an event to every watcher interested in its key. The caller
+// must hold the key's shard lock.
+func (s *Store) publish(e common.Event) {
+	if s.watchers.count.Load() == 0 {
+		return
+	}
+	s.watchers.mu.RLock()
+	defer s.watchers.mu.RUnlock()
+	for w := range s.watchers.subs {
+		if e.Op == common.EventFlush || strings.HasPrefix(e.Key, w.prefix) {
+			w.send(e)
+		}
+	}
+}
+
+func (s *Store) publishSet(key string, version uint64) {
+	s.publish(common.Event{Key: key, Op: common.EventSet, Version: version})
+}
+
+func (s *Store) publishDelete(reason string, keys ...string) {
+	for _, key := range keys {
+		s.publish(common.Event{Key: key, Op: common.EventDelete, Reason: reason})
+	}
+}
+
+func (w *watcher) send(e common.Event) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if len(w.events) < cap(w.events)-1 {
+		w.events <- e
+		w.behind = false
+		return
+	}
+	if !w.behind {
+		select {
+		case w.events <- common.Event{Op: common.EventOverflow}:
+			w.behind = true
+		default:
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package store
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/johannessarpola/poor-cache-go/internal/common"
+)
+
+func nextEvent(t *testing.T, events <-chan common.Event) common.Event {
+	t.Helper()
+	select {
+	case e := <-events:
+		return e
+	case <-time.After(time.Second):
+		t.Fatalf("expected an event")
+		return common.Event{}
+	}
+}
+
+func TestWatch(t *testing.T) {
+	s := New(WithMaxItems(1))
+	defer s.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	events := s.Watch(ctx, "user:")
+
+	s.Set("other", "ignored", 0)
+	meta, _ := s.SetBytes("user:1", []byte("a"), common.ContentTypeBinary, 0)
+	if e := nextEvent(t, events); e != (common.Event{Key: "user:1", Op: common.EventSet, Version: meta.Version}) {
+		t.Fatalf("unexpected event %+v", e)
+	}
+
+	s.Set("user:2", "b", 0)
+	nextEvent(t, events)
+	if e := nextEvent(t, events); e != (common.Event{Key: "user:1", Op: common.EventDelete, Reason: common.ReasonEvicted}) {
+		t.Fatalf("expected an eviction, got %+v", e)
+	}
+
+	s.Delete("user:2")
+	if e := nextEvent(t, events); e.Op != common.EventDelete || e.Reason != "" {
+		t.Fatalf("expected a plain delete, got %+v", e)
+	}
+
+	s.Flush()
+	if e := nextEvent(t, events); e.Op != common.EventFlush {
+		t.Fatalf("expected a flush, got %+v", e)
+	}
+
+	cancel()
+	for range events {
+	}
+}
+
+func TestWatchExpired(t *testing.T) {
+	s := New()
+	defer s.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	events := s.Watch(ctx, "")
+
+	s.Set("k", "v", time.Millisecond)
+	nextEvent(t, events)
+	time.Sleep(5 * time.Millisecond)
+	s.deleteExpired("k")
+	if e := nextEvent(t, events); e != (common.Event{Key: "k", Op: common.EventDelete, Reason: common.ReasonExpired}) {
+		t.Fatalf("expected an expiration, got %+v", e)
+	}
+}
+
+func TestWatchOverflow(t *testing.T) {
+	s := New(WithWatchBuffer(3))
+	defer s.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	events := s.Watch(ctx, "")
+
+	for range 5 {
+		s.Set("k", "v", 0)
+	}
+	want := []string{common.EventSet, common.EventSet, common.EventOverflow}
+	for _, op := range want {
+		if e := nextEvent(t, events); e.Op != op {
+			t.Fatalf("expected %s, got %+v", op, e)
+		}
+	}
+
+	s.Set("k", "v", 0)
+	if e := nextEvent(t, events); e.Op != common.EventSet {
+		t.Fatalf("expected events to resume, got %+v", e)
+	}
+}
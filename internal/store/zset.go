@@ -0,0 +1,146 @@
+package store
+
+import (
+	"cmp"
+	"math"
+	"slices"
+
+	"github.com/johannessarpola/poor-cache-go/internal/common"
+)
+
+// Sorted sets are kept as a slice ordered by score and then member, so ranges
+// by score and ranks are binary searches or simple scans.
+
+func compareZMembers(a, b common.ZMember) int {
+	if c := cmp.Compare(a.Score, b.Score); c != 0 {
+		return c
+	}
+	return cmp.Compare(a.Member, b.Member)
+}
+
+// zindex returns the position of member in zset, or -1.
+func zindex(zset []common.ZMember, member string) int {
+	return slices.IndexFunc(zset, func(m common.ZMember) bool {
+		return m.Member == member
+	})
+}
+
+// zbounds returns the range of zset with scores between minScore and maxScore,
+// both inclusive.
+func zbounds(zset []common.ZMember, minScore, maxScore float64) (int, int) {
+	lo, _ := slices.BinarySearchFunc(zset, minScore, func(m common.ZMember, score float64) int {
+		return cmp.Compare(m.Score, score)
+	})
+	hi := lo
+	for hi < len(zset) && zset[hi].Score <= maxScore {
+		hi++
+	}
+	return lo, hi
+}
+
+// ZAdd adds members to the sorted set stored under key, creating it if needed,
+// and returns the number of members that were new. Members already in the set
+// get their score updated. Scores must be finite numbers.
+func (s *Store) ZAdd(key string, members ...common.ZMember) (int, error) {
+	for _, m := range members {
+		if math.IsNaN(m.Score) || math.IsInf(m.Score, 0) {
+			return 0, common.ErrBadScore
+		}
+	}
+
+	added := 0
+	err := s.modify(key, common.KindZSet, func(v *common.Value) error {
+		if len(members) == 0 {
+			return errUnchanged
+		}
+		for _, m := range members {
+			if i := zindex(v.ZSet, m.Member); i >= 0 {
+				v.ZSet = slices.Delete(v.ZSet, i, i+1)
+			} else {
+				added++
+			}
+			i, _ := slices.BinarySearchFunc(v.ZSet, m, compareZMembers)
+			v.ZSet = slices.Insert(v.ZSet, i, m)
+		}
+		return nil
+	})
+	return added, err
+}
+
+// ZRem removes members from the sorted set stored under key and returns the
+// number removed. Removing the last member removes the key.
+func (s *Store) ZRem(key string, members ...string) (int, error) {
+	removed := 0
+	err := s.modify(key, common.KindZSet, func(v *common.Value) error {
+		for _, member := range members {
+			if i := zindex(v.ZSet, member); i >= 0 {
+				v.ZSet = slices.Delete(v.ZSet, i, i+1)
+				removed++
+			}
+		}
+		if removed == 0 {
+			return errUnchanged
+		}
+		return nil
+	})
+	return removed, err
+}
+
+// ZScore returns the score of a member of the sorted set stored under key. ok
+// is false when the key or the member is missing.
+func (s *Store) ZScore(key, member string) (float64, bool, error) {
+	v, exists, err := s.view(key, common.KindZSet)
+	if !exists {
+		return 0, false, err
+	}
+	i := zindex(v.ZSet, member)
+	if i < 0 {
+		return 0, false, nil
+	}
+	return v.ZSet[i].Score, true, nil
+}
+
+// ZRank returns the zero-based position of a member in the sorted set stored
+// under key, lowest score first. ok is false when the key or the member is
+// missing.
+func (s *Store) ZRank(key, member string) (int, bool, error) {
+	v, exists, err := s.view(key, common.KindZSet)
+	if !exists {
+		return 0, false, err
+	}
+	i := zindex(v.ZSet, member)
+	return i, i >= 0, nil
+}
+
+// ZRangeByScore returns the members of the sorted set stored under key with
+// scores between minScore and maxScore, both inclusive, lowest score first. A
+// positive limit returns at most that many members. Use math.Inf for open
+// ranges. The result is nil when the key is missing.
+func (s *Store) ZRangeByScore(key string, minScore, maxScore float64, limit int) ([]common.ZMember, error) {
+	v, exists, err := s.view(key, common.KindZSet)
+	if !exists {
+		return nil, err
+	}
+	lo, hi := zbounds(v.ZSet, minScore, maxScore)
+	if limit > 0 {
+		hi = lo + min(limit, hi-lo)
+	}
+	return slices.Clone(v.ZSet[lo:hi]), nil
+}
+
+// ZRemRangeByScore removes the members of the sorted set stored under key with
+// scores between minScore and maxScore, both inclusive, and returns the number
+// removed.
+func (s *Store) ZRemRangeByScore(key string, minScore, maxScore float64) (int, error) {
+	removed := 0
+	err := s.modify(key, common.KindZSet, func(v *common.Value) error {
+		lo, hi := zbounds(v.ZSet, minScore, maxScore)
+		if lo == hi {
+			return errUnchanged
+		}
+		v.ZSet = slices.Delete(v.ZSet, lo, hi)
+		removed = hi - lo
+		return nil
+	})
+	return removed, err
+}
@@ -0,0 +1,77 @@
+package store
+
+import (
+	"errors"
+	"math"
+	"slices"
+	"testing"
+
+	"github.com/johannessarpola/poor-cache-go/internal/common"
+)
+
+func members(zset []common.ZMember) []string {
+	names := make([]string, len(zset))
+	for i, m := range zset {
+		names[i] = m.Member
+	}
+	return names
+}
+
+func TestSortedSet(t *testing.T) {
+	s := New()
+	defer s.Close()
+
+	n, err := s.ZAdd("board",
+		common.ZMember{Member: "carol", Score: 30},
+		common.ZMember{Member: "alice", Score: 10},
+		common.ZMember{Member: "bob", Score: 20},
+		common.ZMember{Member: "dave", Score: 20},
+	)
+	if err != nil || n != 4 {
+		t.Fatalf("expected 4 new members, got %d (%v)", n, err)
+	}
+	if n, _ := s.ZAdd("board", common.ZMember{Member: "alice", Score: 40}); n != 0 {
+		t.Fatalf("expected a score update not to count as new, got %d", n)
+	}
+
+	if score, ok, _ := s.ZScore("board", "alice"); !ok || score != 40 {
+		t.Fatalf("expected alice at 40, got %v", score)
+	}
+	if rank, ok, _ := s.ZRank("board", "dave"); !ok || rank != 1 {
+		t.Fatalf("expected dave ranked after bob on equal scores, got %d", rank)
+	}
+	if _, ok, _ := s.ZRank("board", "nobody"); ok {
+		t.Fatalf("expected missing member to have no rank")
+	}
+
+	all, _ := s.ZRangeByScore("board", math.Inf(-1), math.Inf(1), 0)
+	if !slices.Equal(members(all), []string{"bob", "dave", "carol", "alice"}) {
+		t.Fatalf("unexpected order: %v", members(all))
+	}
+	mid, _ := s.ZRangeByScore("board", 20, 30, 2)
+	if !slices.Equal(members(mid), []string{"bob", "dave"}) {
+		t.Fatalf("unexpected range: %v", members(mid))
+	}
+
+	if n, _ := s.ZRemRangeByScore("board", 20, 20); n != 2 {
+		t.Fatalf("expected 2 members removed by score, got %d", n)
+	}
+	if n, _ := s.ZRem("board", "carol", "nobody"); n != 1 {
+		t.Fatalf("expected 1 member removed, got %d", n)
+	}
+	s.ZRem("board", "alice")
+	if s.Has("board") {
+		t.Fatalf("expected the key to go with its last member")
+	}
+}
+
+func TestSortedSetRejectsBadScores(t *testing.T) {
+	s := New()
+	defer s.Close()
+
+	for _, score := range []float64{math.NaN(), math.Inf(1)} {
+		if _, err := s.ZAdd("z", common.ZMember{Member: "m", Score: score}); !errors.Is(err, common.ErrBadScore) {
+			t.Fatalf("expected bad score for %v, got %v", score, err)
+		}
+	}
+}
@@ -3,13 +3,17 @@ package udp
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"math"
 	"net"
+	"strconv"
 	"sync"
 	"time"
 
 	"github.com/johannessarpola/poor-cache-go/internal/common"
 	"github.com/johannessarpola/poor-cache-go/internal/logger"
+	"github.com/johannessarpola/poor-cache-go/internal/pubsub"
 )
 
 type Duration struct {
@@ -37,29 +41,149 @@ func (d Duration) MarshalJSON() (b []byte, err error) {
 	return []byte(fmt.Sprintf(`"%s"`, d.String())), nil
 }
 
+// Envelope is both the request and the response of the UDP protocol. JSON
+// values travel in Value untouched, anything else goes base64 encoded in Data
+// together with its ContentType.
 type Envelope struct {
-	Cmd     string   `json:"cmd"`
-	Key     string   `json:"key,omitempty"`
-	Value   any      `json:"value,omitempty"`
-	Error   string   `json:"error,omitempty"`
-	Success bool     `json:"succes"`
-	TTL     Duration `json:"ttl,omitzero"`
+	Cmd         string            `json:"cmd"`
+	NS          string            `json:"ns,omitempty"` // namespace, the default one when empty
+	Key         string            `json:"key,omitempty"`
+	Value       json.RawMessage   `json:"value,omitempty"`
+	Data        []byte            `json:"data,omitempty"`
+	ContentType string            `json:"content_type,omitempty"`
+	Meta        *common.Meta      `json:"meta,omitempty"`
+	Version     uint64            `json:"version,omitempty"` // expected version for CAS
+	Delta       int64             `json:"delta,omitempty"`   // INCR and DECR step, defaults to 1
+	Error       string            `json:"error,omitempty"`
+	Success     bool              `json:"succes"`
+	TTL         Duration          `json:"ttl,omitzero"`
+	Keys        []string          `json:"keys,omitempty"`      // MGET and set operation keys, SCAN results
+	Match       string            `json:"match,omitempty"`     // SCAN and DELETEMATCH pattern
+	Cursor      string            `json:"cursor,omitempty"`    // SCAN cursor, empty once complete
+	Count       int               `json:"count,omitempty"`     // SCAN page size
+	Field       string            `json:"field,omitempty"`     // hash field
+	Fields      map[string]string `json:"fields,omitempty"`    // HSET fields, HGETALL results
+	Values      []string          `json:"values,omitempty"`    // pushed values and set members, LRANGE and set results
+	Start       int               `json:"start,omitempty"`     // LRANGE first index
+	Stop        *int              `json:"stop,omitempty"`      // LRANGE last index, the end of the list when missing
+	Timeout     Duration          `json:"timeout,omitzero"`    // BLPOP and BRPOP wait, bounded by the handler timeout
+	Member      string            `json:"member,omitempty"`    // set and sorted set member
+	Members     []common.ZMember  `json:"members,omitempty"`   // ZADD members, ZRANGEBYSCORE results
+	Min         *float64          `json:"min,omitempty"`       // lowest score of a range, unbounded when missing
+	Max         *float64          `json:"max,omitempty"`       // highest score of a range, unbounded when missing
+	Channel     string            `json:"channel,omitempty"`   // PUBLISH channel, SUBSCRIBE channel or pattern
+	Message     string            `json:"message,omitempty"`   // published message
+	Items       []BatchItem       `json:"items,omitempty"`     // MSET entries and TX operations
+	Results     []BatchResult     `json:"results,omitempty"`   // MGET and MSET results
+	Truncated   bool              `json:"truncated,omitempty"` // results were dropped to fit the datagram
 }
 
+// BatchItem is a single write of an MSET request or an operation of a TX.
+type BatchItem struct {
+	Op          string          `json:"op,omitempty"` // TX only: set, delete or cas
+	Key         string          `json:"key"`
+	Value       json.RawMessage `json:"value,omitempty"`
+	Data        []byte          `json:"data,omitempty"`
+	ContentType string          `json:"content_type,omitempty"`
+	TTL         Duration        `json:"ttl,omitzero"`
+	Version     uint64          `json:"version,omitempty"` // expected version of a TX cas
+}
+
+// BatchResult is the outcome of MGET or MSET for one key.
+type BatchResult struct {
+	Key         string          `json:"key"`
+	Success     bool            `json:"succes"`
+	Value       json.RawMessage `json:"value,omitempty"`
+	Data        []byte          `json:"data,omitempty"`
+	ContentType string          `json:"content_type,omitempty"`
+	Meta        *common.Meta    `json:"meta,omitempty"`
+	Error       string          `json:"error,omitempty"`
+}
+
+var (
+	errNoValue          = errors.New("value or data required")
+	errNoSelector       = errors.New("prefix or pattern required")
+	errUnknownNamespace = errors.New("unknown namespace")
+	errNoPubSub         = errors.New("pub/sub is not enabled")
+	errNoChannel        = errors.New("channel required")
+	errTooManySubs      = errors.New("too many subscriptions")
+)
+
+// Namespaces gives access to the stores of all namespaces.
+type Namespaces interface {
+	Namespace(name string) (Store, bool)
+	Names() []string
+	FlushAll() (int, error)
+}
+
+// defaultNamespace is the name of the store used for requests without ns.
+const defaultNamespace = "default"
+
+// maxDatagramSize is the largest payload a UDP datagram can carry over IPv4.
+const maxDatagramSize = 65507
+
 type Server struct {
 	addr           *net.UDPAddr
 	conn           *net.Conn
 	mainQuit       chan struct{}
 	handlerTimeout time.Duration
 	store          Store
+	namespaces     Namespaces
+	broker         *pubsub.Broker
+	subsMu         sync.Mutex
+	subs           map[string]*subscriber // by client address and pattern
+	subsPerAddr    map[string]int
 	wg             *sync.WaitGroup
 }
 
 type Store interface {
-	Set(key string, value any, ttl time.Duration) error
-	Get(key string, dest any) (*common.Meta, error)
+	SetBytes(key string, data []byte, contentType string, ttl time.Duration) (*common.Meta, error)
+	CompareAndSet(key string, data []byte, contentType string, ttl time.Duration, version uint64) (*common.Meta, error)
+	SetNX(key string, data []byte, contentType string, ttl time.Duration) (*common.Meta, error)
+	SetXX(key string, data []byte, contentType string, ttl time.Duration) (*common.Meta, error)
+	GetBytes(key string) ([]byte, *common.Meta, error)
 	Delete(key string) error
 	Has(key string) bool
+	TTL(key string) (time.Duration, bool)
+	Expire(key string, ttl time.Duration) error
+	Persist(key string) error
+	Touch(key string) error
+	Incr(key string, delta int64, ttl time.Duration) (int64, error)
+	Decr(key string, delta int64, ttl time.Duration) (int64, error)
+	MGet(keys []string) []common.Result
+	MSet(entries []common.Entry) []common.Result
+	Tx(ops []common.TxOp) ([]common.Result, error)
+	Scan(pattern, cursor string, count int) ([]string, string, error)
+	DeletePrefix(prefix string) (int, error)
+	DeleteMatching(pattern string) (int, error)
+	Flush() (int, error)
+	HSet(key string, fields map[string]string) (int, error)
+	HGet(key, field string) (string, bool, error)
+	HGetAll(key string) (map[string]string, error)
+	HDel(key string, fields ...string) (int, error)
+	HIncrBy(key, field string, delta int64) (int64, error)
+	LPush(key string, values ...string) (int, error)
+	RPush(key string, values ...string) (int, error)
+	LPop(key string) (string, bool, error)
+	RPop(key string) (string, bool, error)
+	BLPop(ctx context.Context, key string, timeout time.Duration) (string, bool, error)
+	BRPop(ctx context.Context, key string, timeout time.Duration) (string, bool, error)
+	LRange(key string, start, stop int) ([]string, error)
+	LLen(key string) (int, error)
+	ZAdd(key string, members ...common.ZMember) (int, error)
+	ZRem(key string, members ...string) (int, error)
+	ZScore(key, member string) (float64, bool, error)
+	ZRank(key, member string) (int, bool, error)
+	ZRangeByScore(key string, minScore, maxScore float64, limit int) ([]common.ZMember, error)
+	ZRemRangeByScore(key string, minScore, maxScore float64) (int, error)
+	SAdd(key string, members ...string) (int, error)
+	SRem(key string, members ...string) (int, error)
+	SIsMember(key, member string) (bool, error)
+	SMembers(key string) ([]string, error)
+	SCard(key string) (int, error)
+	SInter(keys ...string) ([]string, error)
+	SUnion(keys ...string) ([]string, error)
+	SDiff(keys ...string) ([]string, error)
 }
 
 func New(address string, port int, store Store) *Server {
@@ -77,6 +201,34 @@ func New(address string, port int, store Store) *Server {
 	}
 }
 
+// WithNamespaces makes requests with a ns field resolve their store through
+// namespaces. Without it only the default namespace is known.
+func (s *Server) WithNamespaces(namespaces Namespaces) *Server {
+	s.namespaces = namespaces
+	return s
+}
+
+// storeFor returns the store of a namespace, the default store for an empty
+// name.
+func (s *Server) storeFor(ns string) (Store, bool) {
+	switch {
+	case ns == "" || (s.namespaces == nil && ns == defaultNamespace):
+		return s.store, true
+	case s.namespaces != nil:
+		return s.namespaces.Namespace(ns)
+	}
+	return nil, false
+}
+
+// flushAll flushes the stores of all namespaces and returns the number of
+// items discarded.
+func (s *Server) flushAll() (int, error) {
+	if s.namespaces != nil {
+		return s.namespaces.FlushAll()
+	}
+	return s.store.Flush()
+}
+
 // TODO Use context from parent
 func (s *Server) Start() error {
 
@@ -87,21 +239,22 @@ func (s *Server) Start() error {
 	}
 	logger.Infof("Started UDP listener at %s", s.addr.String())
 	defer conn.Close()
-	buffer := make([]byte, 2048)
+	buffer := make([]byte, maxDatagramSize)
 	for {
 		select {
 		case <-s.mainQuit:
 			return nil
 		default:
-			ctx, cancel := context.WithTimeout(context.Background(), s.handlerTimeout)
-			defer cancel()
 			n, clientAddr, err := conn.ReadFromUDP(buffer)
 			if err != nil {
 				fmt.Println("Error reading from UDP:", err)
 				continue
 			}
+			// The buffer is reused for the next read, so the handler gets its own copy.
+			packet := make([]byte, n)
+			copy(packet, buffer[:n])
 			s.wg.Add(1)
-			go handleRequest(ctx, conn, clientAddr, buffer[:n], s.store, s.wg.Done)
+			go s.handleRequest(conn, clientAddr, packet)
 
 		}
 
@@ -109,98 +262,532 @@ func (s *Server) Start() error {
 
 }
 
-func handleRequest(ctx context.Context, conn *net.UDPConn, clientAddr *net.UDPAddr, buffer []byte, store Store, onDone func()) {
-	defer onDone()
+func (s *Server) handleRequest(conn *net.UDPConn, clientAddr *net.UDPAddr, buffer []byte) {
+	defer s.wg.Done()
+	// The timeout starts once the request has arrived, not while waiting for it.
+	ctx, cancel := context.WithTimeout(context.Background(), s.handlerTimeout)
+	defer cancel()
 	var envelope Envelope
 	err := json.Unmarshal(buffer, &envelope)
 	if err != nil {
 		logger.Errorf("Error unmarshalling request: %s", err)
 		return
 	}
-	// TODO Handle errors to response
+
+	response := Envelope{
+		Cmd:     envelope.Cmd,
+		NS:      envelope.NS,
+		Success: true,
+	}
+	store, ok := s.storeFor(envelope.NS)
+	if !ok {
+		response.fail(errUnknownNamespace)
+		respond(conn, clientAddr, response)
+		return
+	}
 	switch envelope.Cmd {
 	case "SET":
-		store.Set(envelope.Key, envelope.Value, envelope.TTL.Duration)
-		response := Envelope{
-			Cmd:     envelope.Cmd,
-			Success: true,
+		data, contentType, err := envelope.payload()
+		if err != nil {
+			response.fail(err)
+			break
 		}
-		res, err := json.Marshal(response)
+		meta, err := store.SetBytes(envelope.Key, data, contentType, envelope.TTL.Duration)
 		if err != nil {
-			logger.Errorf("Error marshalling response: %s", err)
-			return
+			response.fail(err)
 		}
-		_, err = conn.WriteToUDP(res, clientAddr)
+		response.Meta = meta
+	case "SETNX":
+		data, contentType, err := envelope.payload()
 		if err != nil {
-			logger.Errorf("Error writing to UDP: %s", err)
-			return
+			response.fail(err)
+			break
 		}
-
+		meta, err := store.SetNX(envelope.Key, data, contentType, envelope.TTL.Duration)
+		if err != nil {
+			response.fail(err)
+		}
+		response.Meta = meta
+	case "SETXX":
+		data, contentType, err := envelope.payload()
+		if err != nil {
+			response.fail(err)
+			break
+		}
+		meta, err := store.SetXX(envelope.Key, data, contentType, envelope.TTL.Duration)
+		if err != nil {
+			response.fail(err)
+		}
+		response.Meta = meta
+	case "CAS":
+		data, contentType, err := envelope.payload()
+		if err != nil {
+			response.fail(err)
+			break
+		}
+		meta, err := store.CompareAndSet(envelope.Key, data, contentType, envelope.TTL.Duration, envelope.Version)
+		if err != nil {
+			response.fail(err)
+		}
+		response.Meta = meta
 	case "GET":
-		var dest any
-		meta, err := store.Get(envelope.Key, &dest)
+		data, meta, err := store.GetBytes(envelope.Key)
 		if err != nil {
-			logger.Errorf("Error getting key: %s", err)
-			return
+			response.fail(err)
+			break
 		}
-		data := make(map[string]any)
-		data["meta"] = meta
-		data["data"] = dest
 
-		response := Envelope{
-			Cmd:     envelope.Cmd,
-			Success: meta != nil,
-			Value:   data,
+		response.Success = meta != nil
+		response.Meta = meta
+		if meta != nil {
+			if common.IsJSON(meta.ContentType) {
+				response.Value = data
+			} else {
+				response.Data = data
+				response.ContentType = meta.ContentType
+			}
+		}
+	case "DELETE":
+		if err := store.Delete(envelope.Key); err != nil {
+			response.fail(err)
+		}
+	case "HAS":
+		has := store.Has(envelope.Key)
+		response.Value = json.RawMessage(strconv.FormatBool(has))
+	case "TTL":
+		// Missing keys are unsuccessful, keys without expiration have no ttl.
+		ttl, ok := store.TTL(envelope.Key)
+		response.Success = ok
+		if ok && ttl != common.NoExpiry {
+			response.TTL = Duration{ttl}
+		}
+	case "EXPIRE":
+		if err := store.Expire(envelope.Key, envelope.TTL.Duration); err != nil {
+			response.fail(err)
 		}
-		res, err := json.Marshal(response)
+	case "PERSIST":
+		if err := store.Persist(envelope.Key); err != nil {
+			response.fail(err)
+		}
+	case "TOUCH":
+		if err := store.Touch(envelope.Key); err != nil {
+			response.fail(err)
+		}
+	case "INCR", "DECR":
+		delta := envelope.Delta
+		if delta == 0 {
+			delta = 1
+		}
+		apply := store.Incr
+		if envelope.Cmd == "DECR" {
+			apply = store.Decr
+		}
+		n, err := apply(envelope.Key, delta, envelope.TTL.Duration)
 		if err != nil {
-			logger.Errorf("Error marshalling response: %s", err)
-			return
+			response.fail(err)
+			break
 		}
-		_, err = conn.WriteToUDP(res, clientAddr)
+		response.Value = json.RawMessage(strconv.FormatInt(n, 10))
+	case "MGET":
+		response.setResults(store.MGet(envelope.Keys))
+	case "MSET":
+		entries := make([]common.Entry, 0, len(envelope.Items))
+		for _, item := range envelope.Items {
+			e, err := item.entry()
+			if err != nil {
+				response.fail(err)
+				break
+			}
+			entries = append(entries, e)
+		}
+		if response.Success {
+			response.setResults(store.MSet(entries))
+		}
+	case "TX":
+		ops := make([]common.TxOp, 0, len(envelope.Items))
+		for _, item := range envelope.Items {
+			e := common.Entry{Key: item.Key}
+			if item.Op != common.OpDelete {
+				var err error
+				if e, err = item.entry(); err != nil {
+					response.fail(err)
+					break
+				}
+			}
+			ops = append(ops, common.TxOp{
+				Op:          item.Op,
+				Key:         e.Key,
+				Data:        e.Data,
+				ContentType: e.ContentType,
+				TTL:         e.TTL,
+				Version:     item.Version,
+			})
+		}
+		if !response.Success {
+			break
+		}
+		results, err := store.Tx(ops)
 		if err != nil {
-			logger.Errorf("Error writing to UDP: %s", err)
-			return
+			response.fail(err)
+			break
 		}
-	case "DELETE":
-		store.Delete(envelope.Key)
-		response := Envelope{
-			Cmd:     envelope.Cmd,
-			Success: true,
+		response.setResults(results)
+		// Deletes have no metadata but succeeded all the same.
+		for i := range response.Results {
+			response.Results[i].Success = true
+		}
+	case "DELETEPREFIX", "DELETEMATCH":
+		// The prefix travels in Key, the pattern in Match. Neither may be empty,
+		// a typo should not wipe the whole cache.
+		var n int
+		var err error
+		switch {
+		case envelope.Cmd == "DELETEPREFIX" && envelope.Key != "":
+			n, err = store.DeletePrefix(envelope.Key)
+		case envelope.Cmd == "DELETEMATCH" && envelope.Match != "":
+			n, err = store.DeleteMatching(envelope.Match)
+		default:
+			err = errNoSelector
 		}
-		res, err := json.Marshal(response)
 		if err != nil {
-			logger.Errorf("Error marshalling response: %s", err)
-			return
+			response.fail(err)
+		}
+		response.Value = json.RawMessage(strconv.Itoa(n))
+	case "FLUSH", "FLUSHALL":
+		flush := store.Flush
+		if envelope.Cmd == "FLUSHALL" {
+			flush = s.flushAll
 		}
-		_, err = conn.WriteToUDP(res, clientAddr)
+		n, err := flush()
 		if err != nil {
-			logger.Errorf("Error writing to UDP: %s", err)
-			return
+			response.fail(err)
 		}
-	case "HAS":
-		has := store.Has(envelope.Key)
-		response := Envelope{
-			Cmd:   envelope.Cmd,
-			Value: has,
+		response.Value = json.RawMessage(strconv.Itoa(n))
+	case "HSET":
+		n, err := store.HSet(envelope.Key, envelope.Fields)
+		if err != nil {
+			response.fail(err)
 		}
-		res, err := json.Marshal(response)
+		response.Value = json.RawMessage(strconv.Itoa(n))
+	case "HGET":
+		value, ok, err := store.HGet(envelope.Key, envelope.Field)
 		if err != nil {
-			logger.Errorf("Error marshalling response: %s", err)
-			return
+			response.fail(err)
+			break
 		}
-		_, err = conn.WriteToUDP(res, clientAddr)
+		response.Success = ok
+		if ok {
+			response.Value, _ = json.Marshal(value)
+		}
+	case "HGETALL":
+		fields, err := store.HGetAll(envelope.Key)
 		if err != nil {
-			logger.Errorf("Error writing to UDP: %s", err)
-			return
+			response.fail(err)
+			break
+		}
+		response.Success = fields != nil
+		response.Fields = fields
+	case "HDEL":
+		n, err := store.HDel(envelope.Key, envelope.Field)
+		if err != nil {
+			response.fail(err)
+		}
+		response.Value = json.RawMessage(strconv.Itoa(n))
+	case "HINCRBY":
+		delta := envelope.Delta
+		if delta == 0 {
+			delta = 1
+		}
+		n, err := store.HIncrBy(envelope.Key, envelope.Field, delta)
+		if err != nil {
+			response.fail(err)
+			break
+		}
+		response.Value = json.RawMessage(strconv.FormatInt(n, 10))
+	case "LPUSH", "RPUSH":
+		push := store.LPush
+		if envelope.Cmd == "RPUSH" {
+			push = store.RPush
+		}
+		n, err := push(envelope.Key, envelope.Values...)
+		if err != nil {
+			response.fail(err)
+		}
+		response.Value = json.RawMessage(strconv.Itoa(n))
+	case "LPOP", "RPOP", "BLPOP", "BRPOP":
+		var value string
+		var ok bool
+		var err error
+		switch envelope.Cmd {
+		case "LPOP":
+			value, ok, err = store.LPop(envelope.Key)
+		case "RPOP":
+			value, ok, err = store.RPop(envelope.Key)
+		case "BLPOP":
+			value, ok, err = store.BLPop(ctx, envelope.Key, envelope.Timeout.Duration)
+		case "BRPOP":
+			value, ok, err = store.BRPop(ctx, envelope.Key, envelope.Timeout.Duration)
+		}
+		if err != nil {
+			response.fail(err)
+			break
+		}
+		response.Success = ok
+		if ok {
+			response.Value, _ = json.Marshal(value)
+		}
+	case "LRANGE":
+		stop := -1
+		if envelope.Stop != nil {
+			stop = *envelope.Stop
+		}
+		values, err := store.LRange(envelope.Key, envelope.Start, stop)
+		if err != nil {
+			response.fail(err)
+			break
+		}
+		response.Success = values != nil
+		response.Values = values
+	case "LLEN":
+		n, err := store.LLen(envelope.Key)
+		if err != nil {
+			response.fail(err)
+		}
+		response.Value = json.RawMessage(strconv.Itoa(n))
+	case "ZADD":
+		n, err := store.ZAdd(envelope.Key, envelope.Members...)
+		if err != nil {
+			response.fail(err)
+		}
+		response.Value = json.RawMessage(strconv.Itoa(n))
+	case "ZREM":
+		n, err := store.ZRem(envelope.Key, envelope.Member)
+		if err != nil {
+			response.fail(err)
+		}
+		response.Value = json.RawMessage(strconv.Itoa(n))
+	case "ZSCORE":
+		score, ok, err := store.ZScore(envelope.Key, envelope.Member)
+		if err != nil {
+			response.fail(err)
+			break
+		}
+		response.Success = ok
+		if ok {
+			response.Value, _ = json.Marshal(score)
+		}
+	case "ZRANK":
+		rank, ok, err := store.ZRank(envelope.Key, envelope.Member)
+		if err != nil {
+			response.fail(err)
+			break
+		}
+		response.Success = ok
+		if ok {
+			response.Value = json.RawMessage(strconv.Itoa(rank))
+		}
+	case "ZRANGEBYSCORE":
+		minScore, maxScore := envelope.scores()
+		members, err := store.ZRangeByScore(envelope.Key, minScore, maxScore, envelope.Count)
+		if err != nil {
+			response.fail(err)
+			break
+		}
+		response.Success = members != nil
+		response.Members = members
+	case "ZREMRANGEBYSCORE":
+		minScore, maxScore := envelope.scores()
+		n, err := store.ZRemRangeByScore(envelope.Key, minScore, maxScore)
+		if err != nil {
+			response.fail(err)
+		}
+		response.Value = json.RawMessage(strconv.Itoa(n))
+	case "SADD":
+		n, err := store.SAdd(envelope.Key, envelope.Values...)
+		if err != nil {
+			response.fail(err)
+		}
+		response.Value = json.RawMessage(strconv.Itoa(n))
+	case "SREM":
+		n, err := store.SRem(envelope.Key, envelope.Member)
+		if err != nil {
+			response.fail(err)
+		}
+		response.Value = json.RawMessage(strconv.Itoa(n))
+	case "SISMEMBER":
+		ok, err := store.SIsMember(envelope.Key, envelope.Member)
+		if err != nil {
+			response.fail(err)
+		}
+		response.Value = json.RawMessage(strconv.FormatBool(ok))
+	case "SMEMBERS":
+		members, err := store.SMembers(envelope.Key)
+		if err != nil {
+			response.fail(err)
+			break
+		}
+		response.Success = members != nil
+		response.Values = members
+	case "SCARD":
+		n, err := store.SCard(envelope.Key)
+		if err != nil {
+			response.fail(err)
+		}
+		response.Value = json.RawMessage(strconv.Itoa(n))
+	case "SINTER", "SUNION", "SDIFF":
+		op := store.SInter
+		switch envelope.Cmd {
+		case "SUNION":
+			op = store.SUnion
+		case "SDIFF":
+			op = store.SDiff
+		}
+		members, err := op(envelope.Keys...)
+		if err != nil {
+			response.fail(err)
+			break
+		}
+		response.Values = members
+	case "PUBLISH":
+		if err := s.pubsubRequest(envelope.Channel); err != nil {
+			response.fail(err)
+			break
+		}
+		n := s.broker.Publish(envelope.Channel, envelope.Message)
+		response.Value = json.RawMessage(strconv.Itoa(n))
+	case "SUBSCRIBE":
+		if err := s.pubsubRequest(envelope.Channel); err != nil {
+			response.fail(err)
+			break
+		}
+		ttl, err := s.subscribe(conn, clientAddr, envelope.Channel, envelope.TTL.Duration)
+		if err != nil {
+			response.fail(err)
+			break
+		}
+		response.TTL.Duration = ttl
+	case "UNSUBSCRIBE":
+		if err := s.pubsubRequest(envelope.Channel); err != nil {
+			response.fail(err)
+			break
+		}
+		response.Success = s.unsubscribe(clientAddr.String() + " " + envelope.Channel)
+	case "SCAN":
+		if err := response.scan(store, envelope.Match, envelope.Cursor, envelope.Count); err != nil {
+			response.fail(err)
 		}
 	default:
 		logger.Errorf("Unknown command: %s", envelope.Cmd)
+		return
+	}
+
+	respond(conn, clientAddr, response)
+}
+
+// payload returns the value to store from a request, preferring binary data.
+// A request carrying neither is rejected.
+func (e *Envelope) payload() ([]byte, string, error) {
+	switch {
+	case e.Data != nil:
+		return e.Data, e.ContentType, nil
+	case e.Value != nil:
+		return e.Value, common.ContentTypeJSON, nil
+	}
+	return nil, "", errNoValue
+}
+
+// scores returns the score range of a request, open at either end when the
+// bound is missing.
+func (e *Envelope) scores() (float64, float64) {
+	minScore, maxScore := math.Inf(-1), math.Inf(1)
+	if e.Min != nil {
+		minScore = *e.Min
+	}
+	if e.Max != nil {
+		maxScore = *e.Max
+	}
+	return minScore, maxScore
+}
+
+// entry converts the item to a store entry. An item carrying neither value
+// nor data is rejected.
+func (item BatchItem) entry() (common.Entry, error) {
+	e := common.Entry{Key: item.Key, Data: item.Data, ContentType: item.ContentType, TTL: item.TTL.Duration}
+	if item.Data == nil {
+		e.Data, e.ContentType = item.Value, common.ContentTypeJSON
+	}
+	if e.Data == nil {
+		return e, errNoValue
+	}
+	return e, nil
+}
+
+// scan adds a page of keys to a response, asking for smaller pages until the
+// response fits in a single datagram.
+func (e *Envelope) scan(store Store, match, cursor string, count int) error {
+	for {
+		keys, next, err := store.Scan(match, cursor, count)
+		if err != nil {
+			return err
+		}
+		e.Keys, e.Cursor = keys, next
+		b, err := json.Marshal(e)
+		if err != nil || len(b) <= maxDatagramSize || len(keys) <= 1 {
+			return err
+		}
+		count = len(keys) / 2
+	}
+}
+
+// setResults adds batch results to a response, dropping the ones that would
+// not fit in a single datagram. A missing key is an unsuccessful result
+// without an error.
+func (e *Envelope) setResults(results []common.Result) {
+	size := 0
+	if b, err := json.Marshal(e); err == nil {
+		size = len(b) + len(`,"results":[]`)
 	}
+	for _, r := range results {
+		res := BatchResult{Key: r.Key, Success: r.Err == nil && r.Meta != nil, Meta: r.Meta}
+		switch {
+		case r.Err != nil:
+			res.Error = r.Err.Error()
+		case r.Data != nil && r.Meta != nil && common.IsJSON(r.Meta.ContentType):
+			res.Value = r.Data
+		case r.Data != nil && r.Meta != nil:
+			res.Data = r.Data
+			res.ContentType = r.Meta.ContentType
+		}
 
+		b, err := json.Marshal(res)
+		if err != nil || size+len(b)+1 > maxDatagramSize-len(`,"truncated":true`) {
+			e.Truncated = true
+			return
+		}
+		size += len(b) + 1
+		e.Results = append(e.Results, res)
+	}
+}
+
+func (e *Envelope) fail(err error) {
+	e.Success = false
+	e.Error = err.Error()
+}
+
+func respond(conn *net.UDPConn, clientAddr *net.UDPAddr, response Envelope) {
+	res, err := json.Marshal(response)
+	if err != nil {
+		logger.Errorf("Error marshalling response: %s", err)
+		return
+	}
+	_, err = conn.WriteToUDP(res, clientAddr)
+	if err != nil {
+		logger.Errorf("Error writing to UDP: %s", err)
+	}
 }
 
 func (s *Server) Close() {
 	s.mainQuit <- struct{}{}
+	s.closeSubscriptions()
 	s.wg.Wait() // TODO This should timeout
 }
@@ -0,0 +1,152 @@
+package udp
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/johannessarpola/poor-cache-go/internal/common"
+	"github.com/stretchr/testify/assert"
+)
+
+// scanStore answers Scan with count keys of keySize bytes and records the
+// counts it was asked for. Other Store methods are not implemented.
+type scanStore struct {
+	Store
+	keySize int
+	err     error
+	counts  []int
+}
+
+func (s *scanStore) Scan(pattern, cursor string, count int) ([]string, string, error) {
+	s.counts = append(s.counts, count)
+	if s.err != nil {
+		return nil, "", s.err
+	}
+	keys := make([]string, count)
+	for i := range keys {
+		keys[i] = strings.Repeat("k", s.keySize)
+	}
+	return keys, "next", nil
+}
+
+func TestEnvelopeScan(t *testing.T) {
+	tests := []struct {
+		name           string
+		keySize        int
+		count          int
+		err            error
+		expectedCounts []int
+		expectedKeys   int
+	}{
+		{
+			name:           "Page fits",
+			keySize:        10,
+			count:          100,
+			expectedCounts: []int{100},
+			expectedKeys:   100,
+		},
+		{
+			name:           "Page halved until it fits",
+			keySize:        1000,
+			count:          200,
+			expectedCounts: []int{200, 100, 50},
+			expectedKeys:   50,
+		},
+		{
+			name:           "Single key is returned even when too large",
+			keySize:        maxDatagramSize,
+			count:          4,
+			expectedCounts: []int{4, 2, 1},
+			expectedKeys:   1,
+		},
+		{
+			name:           "Scan error",
+			count:          10,
+			err:            errors.New("bad cursor"),
+			expectedCounts: []int{10},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := &scanStore{keySize: tt.keySize, err: tt.err}
+			response := Envelope{Cmd: "SCAN", Success: true}
+
+			err := response.scan(store, "*", "", tt.count)
+
+			assert.Equal(t, tt.err, err)
+			assert.Equal(t, tt.expectedCounts, store.counts)
+			assert.Len(t, response.Keys, tt.expectedKeys)
+			if tt.err == nil && tt.expectedKeys > 1 {
+				b, _ := json.Marshal(response)
+				assert.LessOrEqual(t, len(b), maxDatagramSize)
+			}
+		})
+	}
+}
+
+func TestEnvelopeSetResults(t *testing.T) {
+	jsonMeta := &common.Meta{ContentType: common.ContentTypeJSON}
+	binaryMeta := &common.Meta{ContentType: common.ContentTypeBinary}
+	large := json.RawMessage(`"` + strings.Repeat("v", 30000) + `"`)
+
+	tests := []struct {
+		name              string
+		results           []common.Result
+		expected          []BatchResult
+		expectedTruncated bool
+	}{
+		{
+			name: "Values, data, missing keys and errors",
+			results: []common.Result{
+				{Key: "json", Data: []byte(`{"n":1}`), Meta: jsonMeta},
+				{Key: "binary", Data: []byte{0x00, 0xff}, Meta: binaryMeta},
+				{Key: "missing"},
+				{Key: "failed", Err: common.ErrTooLarge},
+			},
+			expected: []BatchResult{
+				{Key: "json", Success: true, Value: json.RawMessage(`{"n":1}`), Meta: jsonMeta},
+				{Key: "binary", Success: true, Data: []byte{0x00, 0xff}, ContentType: common.ContentTypeBinary, Meta: binaryMeta},
+				{Key: "missing"},
+				{Key: "failed", Error: common.ErrTooLarge.Error()},
+			},
+		},
+		{
+			name: "Results past the datagram size are dropped",
+			results: []common.Result{
+				{Key: "a", Data: large, Meta: jsonMeta},
+				{Key: "b", Data: large, Meta: jsonMeta},
+				{Key: "c", Data: large, Meta: jsonMeta},
+				{Key: "d", Data: []byte(`1`), Meta: jsonMeta},
+			},
+			expected: []BatchResult{
+				{Key: "a", Success: true, Value: large, Meta: jsonMeta},
+				{Key: "b", Success: true, Value: large, Meta: jsonMeta},
+			},
+			expectedTruncated: true,
+		},
+		{
+			name: "Single result too large",
+			results: []common.Result{
+				{Key: "huge", Data: []byte(strings.Repeat("x", maxDatagramSize)), Meta: binaryMeta},
+			},
+			expectedTruncated: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			response := Envelope{Cmd: "MGET", Success: true}
+
+			response.setResults(tt.results)
+
+			assert.Equal(t, tt.expected, response.Results)
+			assert.Equal(t, tt.expectedTruncated, response.Truncated)
+			b, err := json.Marshal(response)
+			assert.NoError(t, err)
+			assert.LessOrEqual(t, len(b), maxDatagramSize)
+		})
+	}
+}
@@ -0,0 +1,131 @@
+package udp
+
+import (
+	"context"
+	"net"
+	"time"
+
+	"github.com/johannessarpola/poor-cache-go/internal/pubsub"
+)
+
+// Subscriptions are not confirmed by the client, so anyone able to spoof a
+// source address could point messages at it. The limits keep the traffic
+// such a subscription can cause small and short-lived.
+const (
+	defaultSubscribeTTL     = time.Minute
+	maxSubscribeTTL         = 5 * time.Minute
+	maxSubscriptionsPerAddr = 16
+	maxSubscriptions        = 1024
+)
+
+// subscriber forwards the messages of one pattern to one client address.
+// Clients renew it by subscribing again before it expires.
+type subscriber struct {
+	addr    string
+	cancel  context.CancelFunc
+	timer   *time.Timer
+	expires time.Time
+}
+
+// WithPubSub enables PUBLISH, SUBSCRIBE and UNSUBSCRIBE, delivering messages
+// through broker.
+func (s *Server) WithPubSub(broker *pubsub.Broker) *Server {
+	s.broker = broker
+	return s
+}
+
+func (s *Server) pubsubRequest(channel string) error {
+	if s.broker == nil {
+		return errNoPubSub
+	}
+	if channel == "" {
+		return errNoChannel
+	}
+	return nil
+}
+
+// subscribe makes the server send the messages of channels matching pattern
+// to addr as MESSAGE datagrams until ttl passes without a renewal, and
+// returns the ttl granted. Subscribing again with the same pattern renews it.
+// New subscriptions fail once the address or the server has reached its limit.
+func (s *Server) subscribe(conn *net.UDPConn, addr *net.UDPAddr, pattern string, ttl time.Duration) (time.Duration, error) {
+	if ttl <= 0 {
+		ttl = defaultSubscribeTTL
+	}
+	ttl = min(ttl, maxSubscribeTTL)
+	key := addr.String() + " " + pattern
+
+	s.subsMu.Lock()
+	defer s.subsMu.Unlock()
+	if sub, ok := s.subs[key]; ok {
+		sub.expires = time.Now().Add(ttl)
+		sub.timer.Reset(ttl)
+		return ttl, nil
+	}
+	if len(s.subs) >= maxSubscriptions || s.subsPerAddr[addr.String()] >= maxSubscriptionsPerAddr {
+		return 0, errTooManySubs
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	sub := &subscriber{addr: addr.String(), cancel: cancel, expires: time.Now().Add(ttl)}
+	sub.timer = time.AfterFunc(ttl, func() { s.expire(key, sub) })
+	if s.subs == nil {
+		s.subs = make(map[string]*subscriber)
+		s.subsPerAddr = make(map[string]int)
+	}
+	s.subs[key] = sub
+	s.subsPerAddr[sub.addr]++
+
+	messages := s.broker.Subscribe(ctx, pattern)
+	go func() {
+		for msg := range messages {
+			respond(conn, addr, Envelope{Cmd: "MESSAGE", Channel: msg.Channel, Message: msg.Message, Success: true})
+		}
+	}()
+	return ttl, nil
+}
+
+// expire drops a subscriber whose timer fired, unless it was renewed while
+// the timer was firing.
+func (s *Server) expire(key string, sub *subscriber) {
+	s.subsMu.Lock()
+	defer s.subsMu.Unlock()
+	if s.subs[key] != sub {
+		return
+	}
+	if remaining := time.Until(sub.expires); remaining > 0 {
+		sub.timer.Reset(remaining)
+		return
+	}
+	s.drop(key, sub)
+}
+
+// unsubscribe drops a subscriber and reports whether it existed.
+func (s *Server) unsubscribe(key string) bool {
+	s.subsMu.Lock()
+	defer s.subsMu.Unlock()
+	sub, ok := s.subs[key]
+	if ok {
+		sub.timer.Stop()
+		s.drop(key, sub)
+	}
+	return ok
+}
+
+func (s *Server) closeSubscriptions() {
+	s.subsMu.Lock()
+	defer s.subsMu.Unlock()
+	for key, sub := range s.subs {
+		sub.timer.Stop()
+		s.drop(key, sub)
+	}
+}
+
+// drop cancels a subscriber and forgets it. The caller holds subsMu.
+func (s *Server) drop(key string, sub *subscriber) {
+	sub.cancel()
+	delete(s.subs, key)
+	if s.subsPerAddr[sub.addr]--; s.subsPerAddr[sub.addr] <= 0 {
+		delete(s.subsPerAddr, sub.addr)
+	}
+}
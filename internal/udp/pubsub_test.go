@@ -0,0 +1,43 @@
+package udp
+
+import (
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/johannessarpola/poor-cache-go/internal/pubsub"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSubscribeLimits(t *testing.T) {
+	s := New("127.0.0.1", 0, nil).WithPubSub(pubsub.New(0))
+	defer s.closeSubscriptions()
+	client := &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 4000}
+
+	ttl, err := s.subscribe(nil, client, "news", 24*time.Hour)
+	assert.NoError(t, err)
+	assert.Equal(t, maxSubscribeTTL, ttl)
+
+	for i := 1; i < maxSubscriptionsPerAddr; i++ {
+		_, err := s.subscribe(nil, client, fmt.Sprintf("channel-%d", i), 0)
+		assert.NoError(t, err)
+	}
+	_, err = s.subscribe(nil, client, "one-too-many", 0)
+	assert.Equal(t, errTooManySubs, err)
+
+	_, err = s.subscribe(nil, client, "news", 0)
+	assert.NoError(t, err, "renewing an existing subscription is always allowed")
+
+	assert.True(t, s.unsubscribe(client.String()+" news"))
+	_, err = s.subscribe(nil, client, "one-too-many", 0)
+	assert.NoError(t, err, "unsubscribing frees a slot")
+
+	for port := 4001; len(s.subs) < maxSubscriptions; port++ {
+		other := &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1), Port: port}
+		_, err := s.subscribe(nil, other, "news", 0)
+		assert.NoError(t, err)
+	}
+	_, err = s.subscribe(nil, &net.UDPAddr{IP: net.IPv4(127, 0, 0, 2), Port: 4000}, "news", 0)
+	assert.Equal(t, errTooManySubs, err)
+}
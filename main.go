@@ -6,11 +6,15 @@ import (
 	"os"
 	"os/signal"
 	"runtime/debug"
+	"strconv"
+	"strings"
 	"syscall"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/johannessarpola/poor-cache-go/internal/logger"
 	"github.com/johannessarpola/poor-cache-go/internal/middleware"
+	"github.com/johannessarpola/poor-cache-go/internal/pubsub"
 	"github.com/johannessarpola/poor-cache-go/internal/rest"
 	"github.com/johannessarpola/poor-cache-go/internal/store"
 	"github.com/johannessarpola/poor-cache-go/internal/udp"
@@ -26,9 +30,167 @@ func BuildVersion() string {
 	return info.Main.Version
 }
 
+// storeOptions reads the options of a store from environment variables whose
+// names start with prefix.
+func storeOptions(prefix string) []store.Option {
+	var opts []store.Option
+	if v := os.Getenv(prefix + "SHARDS"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			logger.Errorf("Invalid %sSHARDS %s", prefix, v)
+			os.Exit(1)
+		}
+		opts = append(opts, store.WithShards(n))
+	}
+	if v := os.Getenv(prefix + "MAX_BYTES"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			logger.Errorf("Invalid %sMAX_BYTES %s", prefix, v)
+			os.Exit(1)
+		}
+		opts = append(opts, store.WithMaxBytes(n))
+	}
+	if v := os.Getenv(prefix + "MAX_ITEMS"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			logger.Errorf("Invalid %sMAX_ITEMS %s", prefix, v)
+			os.Exit(1)
+		}
+		opts = append(opts, store.WithMaxItems(n))
+	}
+	if v := os.Getenv(prefix + "EVICTION_POLICY"); v != "" {
+		p, err := store.PolicyByName(v)
+		if err != nil {
+			logger.Errorf("Invalid %sEVICTION_POLICY: %s", prefix, err)
+			os.Exit(1)
+		}
+		opts = append(opts, store.WithEvictionPolicy(p))
+	}
+	if v := os.Getenv(prefix + "CODEC"); v != "" {
+		c, err := store.CodecByName(v)
+		if err != nil {
+			logger.Errorf("Invalid %sCODEC: %s", prefix, err)
+			os.Exit(1)
+		}
+		opts = append(opts, store.WithCodec(c))
+	}
+	if v := os.Getenv(prefix + "COMPRESSION_THRESHOLD"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			logger.Errorf("Invalid %sCOMPRESSION_THRESHOLD %s", prefix, v)
+			os.Exit(1)
+		}
+		opts = append(opts, store.WithCompressionThreshold(n))
+	}
+	if v := os.Getenv(prefix + "DEFAULT_TTL"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			logger.Errorf("Invalid %sDEFAULT_TTL %s", prefix, v)
+			os.Exit(1)
+		}
+		opts = append(opts, store.WithDefaultTTL(d))
+	}
+	if v := os.Getenv(prefix + "WATCH_BUFFER"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			logger.Errorf("Invalid %sWATCH_BUFFER %s", prefix, v)
+			os.Exit(1)
+		}
+		opts = append(opts, store.WithWatchBuffer(n))
+	}
+	if v := os.Getenv(prefix + "SNAPSHOT_PATH"); v != "" {
+		var interval time.Duration
+		if i := os.Getenv(prefix + "SNAPSHOT_INTERVAL"); i != "" {
+			d, err := time.ParseDuration(i)
+			if err != nil {
+				logger.Errorf("Invalid %sSNAPSHOT_INTERVAL %s", prefix, i)
+				os.Exit(1)
+			}
+			interval = d
+		}
+		opts = append(opts, store.WithSnapshot(v, interval))
+	}
+	if v := os.Getenv(prefix + "AOF_PATH"); v != "" {
+		fsync, err := store.ParseFsyncPolicy(os.Getenv(prefix + "AOF_FSYNC"))
+		if err != nil {
+			logger.Errorf("Invalid %sAOF_FSYNC: %s", prefix, err)
+			os.Exit(1)
+		}
+		var rewriteSize int64
+		if r := os.Getenv(prefix + "AOF_REWRITE_SIZE"); r != "" {
+			n, err := strconv.ParseInt(r, 10, 64)
+			if err != nil {
+				logger.Errorf("Invalid %sAOF_REWRITE_SIZE %s", prefix, r)
+				os.Exit(1)
+			}
+			rewriteSize = n
+		}
+		opts = append(opts, store.WithAppendLog(v, fsync, rewriteSize))
+	}
+	if v := os.Getenv(prefix + "LOADER_URL"); v != "" {
+		var ttl, timeout time.Duration
+		if t := os.Getenv(prefix + "LOADER_TTL"); t != "" {
+			d, err := time.ParseDuration(t)
+			if err != nil {
+				logger.Errorf("Invalid %sLOADER_TTL %s", prefix, t)
+				os.Exit(1)
+			}
+			ttl = d
+		}
+		if t := os.Getenv(prefix + "LOADER_TIMEOUT"); t != "" {
+			d, err := time.ParseDuration(t)
+			if err != nil {
+				logger.Errorf("Invalid %sLOADER_TIMEOUT %s", prefix, t)
+				os.Exit(1)
+			}
+			timeout = d
+		}
+		loader := store.NewHTTPLoader(v, timeout)
+		opts = append(opts, store.WithLoader(os.Getenv(prefix+"LOADER_PREFIX"), loader, ttl))
+	}
+	return opts
+}
+
+// namespaces creates the namespaces listed in NAMESPACES, separated by commas.
+// Each one is configured like the default store, through the same variables
+// prefixed with NS_ and its upper-cased name, e.g. NS_SESSIONS_MAX_BYTES.
+func namespaces(def *store.Store) *store.Namespaces {
+	ns := store.NewNamespaces(def)
+	for _, name := range strings.Split(os.Getenv("NAMESPACES"), ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		prefix := "NS_" + strings.ToUpper(strings.ReplaceAll(name, "-", "_")) + "_"
+		if _, err := ns.Create(name, storeOptions(prefix)...); err != nil {
+			logger.Errorf("Invalid namespace %s: %s", name, err)
+			os.Exit(1)
+		}
+		logger.Infof("Created namespace %s", name)
+	}
+	return ns
+}
+
+// restNamespaces and udpNamespaces hand the namespaces to the servers, which
+// each know stores through their own interface.
+type restNamespaces struct{ *store.Namespaces }
+
+func (n restNamespaces) Namespace(name string) (rest.Store, bool) {
+	s, ok := n.Get(name)
+	return s, ok
+}
+
+type udpNamespaces struct{ *store.Namespaces }
+
+func (n udpNamespaces) Namespace(name string) (udp.Store, bool) {
+	s, ok := n.Get(name)
+	return s, ok
+}
+
 func main() {
 	r := gin.New()
-	store := store.New()
+	ns := namespaces(store.New(storeOptions("")...))
+	def, _ := ns.Get(store.DefaultNamespace)
 
 	logger.SetServiceName("poor-cache-go")
 	if Version != "" {
@@ -40,7 +202,8 @@ func main() {
 	r.Use(middleware.RequestLogger())
 
 	v1group := r.Group("/api/v1")
-	v1Svc := rest.New(store)
+	broker := pubsub.New(0)
+	v1Svc := rest.New(def).WithNamespaces(restNamespaces{ns}).WithPubSub(broker)
 
 	rest.SetupRouter(v1group, v1Svc)
 
@@ -59,7 +222,7 @@ func main() {
 		}
 	}()
 
-	udpServer := udp.New("0.0.0.0", 8081, store)
+	udpServer := udp.New("0.0.0.0", 8081, def).WithNamespaces(udpNamespaces{ns}).WithPubSub(broker)
 	go func() {
 		if err := udpServer.Start(); err != nil {
 			logger.Errorf("Failed to start UDP server %e", err)
@@ -73,7 +236,7 @@ func main() {
 
 	// cleanup
 	udpServer.Close()
-	store.Close()
+	ns.Close()
 
 	os.Exit(0)
 